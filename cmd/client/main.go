@@ -23,34 +23,49 @@ func sendMessage(msg, dest string) error {
 	fmt.Println("Text: " + msg)
 	fmt.Println("Destination: " + dest)
 
-	tmsg := &client.Message{
+	return sendToNode(&client.Message{
 		Text:        msg,
 		Destination: dest,
+	})
+}
+
+// sendToNode writes the message to the client port of the node
+func sendToNode(msg *client.Message) error {
+	buf, err := protobuf.Encode(msg)
+	if err != nil {
+		return err
 	}
-	buf, err1 := protobuf.Encode(tmsg)
-	conn, err2 := net.Dial(Protocol, ServerAdress.String())
-	defer conn.Close()
-	switch {
-	case err1 != nil:
-		return err1
-	case err2 != nil:
-		return err2
+	conn, err := net.Dial(Protocol, ServerAdress.String())
+	if err != nil {
+		return err
 	}
-	conn.Write(buf)
-	return nil
+	defer conn.Close()
+	_, err = conn.Write(buf)
+	return err
 }
 
 func main() {
 
 	// Setup flags with this sintax
 	// go run . -UIPort=10000 -msg=Hello
+	// go run . -server=http://127.0.0.1:8080 -name=nodeA balance <address>
 	var UIPort = flag.Int("UIPort", 10000, "Port for the UI client")
 	var dest = flag.String("Dest", "", "Destination for the private message")
 	var msg = flag.String("msg", "", "Message to be sent")
+	var server = flag.String("server", "http://127.0.0.1:8080", "URL of the HTTP server of the node for the wallet commands")
+	var name = flag.String("name", "", "Name of the node in the HTTP server")
+	flag.Usage = usage
 
 	flag.Parse()
 	ServerAdress.Port = int64(*UIPort)
 
+	if flag.NArg() > 0 {
+		api := client.NewAPI(*server, *name)
+		if e := runCommand(api, flag.Arg(0), flag.Args()[1:]); e != nil {
+			log.Fatal(e)
+		}
+		return
+	}
 	if e := sendMessage(*msg, *dest); e != nil {
 		log.Fatal(e)
 	}
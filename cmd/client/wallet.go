@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ageapps/gambercoin/pkg/client"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage:
+  client [flags]                      send the -msg rumor or private message
+  client [flags] wallet               list the addresses of the node wallet
+  client [flags] wallet new           create an address in the node wallet
+  client [flags] balance <address>    confirmed balance of an address
+  client [flags] history <address>    transactions of an address
+  client [flags] fee [blocks]         fee estimate to be mined within blocks
+  client [flags] status <tx>          status and confirmations of a transaction
+  client [flags] notifications [--since <id>] [address]
+                                      changes of the payments of the node wallet
+  client [flags] send --to <address> --amount <coins> [--from <address>] [--fee <coins>]
+                      [--lockTime <height|unix time>] [--udp]
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+// runCommand of the wallet against the HTTP server of the node
+func runCommand(api *client.API, command string, args []string) error {
+	switch command {
+	case "wallet":
+		return walletCommand(api, args)
+	case "balance":
+		return balanceCommand(api, args)
+	case "history":
+		return historyCommand(api, args)
+	case "fee":
+		return feeCommand(api, args)
+	case "status":
+		return statusCommand(api, args)
+	case "notifications":
+		return notificationsCommand(api, args)
+	case "send":
+		return sendCommand(api, args)
+	}
+	usage()
+	return fmt.Errorf("unknown command %v", command)
+}
+
+func walletCommand(api *client.API, args []string) error {
+	if len(args) == 0 {
+		var wallet interface{}
+		if err := api.GetWallet(&wallet); err != nil {
+			return err
+		}
+		return printJSON(wallet)
+	}
+	if args[0] != "new" {
+		return fmt.Errorf("unknown wallet command %v", args[0])
+	}
+	address, err := api.NewAddress()
+	if err != nil {
+		return err
+	}
+	fmt.Println(address)
+	return nil
+}
+
+func balanceCommand(api *client.API, args []string) error {
+	if len(args) != 1 {
+		return errors.New("balance needs an address")
+	}
+	balance, err := api.GetBalance(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(balance)
+	return nil
+}
+
+func historyCommand(api *client.API, args []string) error {
+	if len(args) != 1 {
+		return errors.New("history needs an address")
+	}
+	var history interface{}
+	if err := api.GetHistory(args[0], &history); err != nil {
+		return err
+	}
+	return printJSON(history)
+}
+
+func feeCommand(api *client.API, args []string) error {
+	blocks := 1
+	if len(args) > 0 {
+		var err error
+		if blocks, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("blocks %v not valid", args[0])
+		}
+	}
+	var estimate interface{}
+	if err := api.EstimateFee(blocks, &estimate); err != nil {
+		return err
+	}
+	return printJSON(estimate)
+}
+
+func statusCommand(api *client.API, args []string) error {
+	if len(args) != 1 {
+		return errors.New("status needs a transaction")
+	}
+	var status interface{}
+	if err := api.GetTransactionStatus(args[0], &status); err != nil {
+		return err
+	}
+	return printJSON(status)
+}
+
+func notificationsCommand(api *client.API, args []string) error {
+	flags := flag.NewFlagSet("notifications", flag.ExitOnError)
+	since := flags.Uint64("since", 0, "Only notifications with a greater id")
+	flags.Parse(args)
+	var notifications interface{}
+	if err := api.GetNotifications(flags.Arg(0), *since, &notifications); err != nil {
+		return err
+	}
+	return printJSON(notifications)
+}
+
+func sendCommand(api *client.API, args []string) error {
+	flags := flag.NewFlagSet("send", flag.ExitOnError)
+	from := flags.String("from", "", "Wallet address paying, any address of the node wallet if empty")
+	to := flags.String("to", "", "Address receiving the coins")
+	amount := flags.Int("amount", 0, "Coins sent")
+	fee := flags.Int("fee", -1, "Coins paid to the miner, estimated by the node if negative")
+	lockTime := flags.Int64("lockTime", 0, "Block height or, from 500000000, median unix time the transaction waits for")
+	udp := flags.Bool("udp", false, "Send through the client port -UIPort instead of the HTTP server")
+	flags.Parse(args)
+	if *to == "" || *amount <= 0 {
+		return errors.New("send needs --to and a positive --amount")
+	}
+	tx := client.ClientTx{In: *from, Out: *to, Amount: *amount, Fee: *fee, LockTime: *lockTime}
+	if *udp {
+		return sendToNode(&client.Message{Transaction: &tx})
+	}
+	hash, err := api.SendTransaction(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(content))
+	return nil
+}
@@ -6,15 +6,19 @@ import (
 	"log"
 	"net"
 	"os"
+	"path"
+	"runtime"
 	"strings"
 
 	"github.com/google/uuid"
 
+	"github.com/ageapps/gambercoin/pkg/blockchain"
 	"github.com/ageapps/gambercoin/pkg/client"
 	"github.com/ageapps/gambercoin/pkg/connection"
 	"github.com/ageapps/gambercoin/pkg/logger"
 	"github.com/ageapps/gambercoin/pkg/node"
 	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/ageapps/gambercoin/pkg/wallet"
 )
 
 // Setup flags with this sintax
@@ -51,10 +55,31 @@ func main() {
 	var UIPort = flag.Int("UIPort", 10000, "Define the port to which the client will connect")
 	// var rtimer = flag.Int("rtimer", 3, "Route rumors sending period in seconds, 0 to disable")
 	var name = flag.String("name", "", "Define the name of the node. By default an uuid is created")
+	var dataDir = flag.String("dataDir", path.Join(utils.GetRootPath(), "._Data"), "Directory where the node stores its chain, empty to keep it in memory")
+	var network = flag.String("network", blockchain.DEFAULT_NETWORK, "Network preset (main, staging, test) or path of a JSON file with the network params")
+	var mempoolCount = flag.Int("mempoolCount", blockchain.DefaultMempoolConfig().MaxCount, "Maximum number of transactions in the pool")
+	var mempoolBytes = flag.Int("mempoolBytes", blockchain.DefaultMempoolConfig().MaxBytes, "Maximum bytes of the transactions in the pool")
+	var mempoolExpiry = flag.Int64("mempoolExpiry", blockchain.DefaultMempoolConfig().Expiry, "Seconds a transaction can wait in the pool")
+	var consensus = flag.String("consensus", blockchain.CONSENSUS_POW, "Consensus engine (pow, instant, interval), instant and interval only in networks that allow unsealed blocks")
+	var sealInterval = flag.Int64("sealInterval", blockchain.DEFAULT_SEAL_INTERVAL, "Seconds between blocks of the interval consensus engine")
+	var miningThreads = flag.Int("miningThreads", runtime.NumCPU(), "Goroutines hashing in the proof of work consensus engine")
+	var mine = flag.Bool("mine", true, "Build and seal blocks, false to run a relay only node")
+	var rewardAddress = flag.String("rewardAddress", "", "Hex address the mining rewards are paid to, the default wallet address by default")
+	var minerKey = flag.String("minerKey", "", "Hex private key imported in the wallet as the default address the rewards are paid to, MINER_KEY is used if empty")
+	var walletPassword = flag.String("walletPassword", "", "Password encrypting the wallet of the node, WALLET_PASSWORD is used if empty, without it the wallet is not stored")
+	var coinSelection = flag.String("coinSelection", wallet.SELECTION_BRANCH_AND_BOUND, "Coin selection of the wallet (bnb, largest)")
+	var dustThreshold = flag.Int("dustThreshold", wallet.DEFAULT_DUST_THRESHOLD, "Change under it is left to the miner instead of creating an output")
 	flag.Var(peers, "peers", "Define the addreses of the rest of the peers to connect to separeted by a colon")
 	flag.Var(&nodepAddr, "nodepAddr", "Define the ip and port to connect and send gossip messages")
 	flag.Parse()
 
+	if env, ok := os.LookupEnv("WALLET_PASSWORD"); ok && *walletPassword == "" {
+		walletPassword = &env
+	}
+	if env, ok := os.LookupEnv("MINER_KEY"); ok && *minerKey == "" {
+		minerKey = &env
+	}
+
 	clientAddress := fmt.Sprintf("%v:%v", nodepAddr.IP, *UIPort)
 	clientChannel := make(chan client.Message)
 	// fmt.Println(clientAddress)
@@ -77,10 +102,35 @@ func main() {
 	if ok {
 		nodepAddr.Set(address)
 	}
-	var node, err = node.NewNode(nodepAddr.String(), *name)
+	var node, err = node.NewNode(nodepAddr.String(), *name, node.Config{
+		DataDir:        *dataDir,
+		Network:        *network,
+		Mempool:        blockchain.MempoolConfig{MaxCount: *mempoolCount, MaxBytes: *mempoolBytes, Expiry: *mempoolExpiry},
+		Consensus:      *consensus,
+		SealInterval:   *sealInterval,
+		MiningThreads:  *miningThreads,
+		DisableMining:  !*mine,
+		RewardAddress:  *rewardAddress,
+		WalletPassword: *walletPassword,
+		CoinSelection:  *coinSelection,
+		DustThreshold:  *dustThreshold,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	if *minerKey != "" {
+		var keyBytes utils.Bytes
+		if err := keyBytes.Set(*minerKey); err != nil {
+			log.Fatal(err)
+		}
+		key, err := blockchain.ParsePrivateKey(keyBytes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := node.SetMinerKey(key); err != nil {
+			log.Fatal(err)
+		}
+	}
 	peersEnv, ok := os.LookupEnv("PEERS")
 	if ok {
 		peers.Set(peersEnv)
@@ -5,8 +5,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path"
+	"runtime"
 
+	"github.com/ageapps/gambercoin/pkg/blockchain"
 	"github.com/ageapps/gambercoin/pkg/http_server"
+	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/ageapps/gambercoin/pkg/wallet"
 	"github.com/rs/cors"
 )
 
@@ -14,7 +19,34 @@ import (
 func main() {
 
 	var UIPort = flag.String("port", "8080", "Port for the UI client")
+	var dataDir = flag.String("dataDir", path.Join(utils.GetRootPath(), "._Data"), "Directory where nodes store their chain, empty to keep it in memory")
+	var network = flag.String("network", blockchain.DEFAULT_NETWORK, "Network preset (main, staging, test) or path of a JSON file with the network params")
+	var mempoolCount = flag.Int("mempoolCount", blockchain.DefaultMempoolConfig().MaxCount, "Maximum number of transactions in the pool")
+	var mempoolBytes = flag.Int("mempoolBytes", blockchain.DefaultMempoolConfig().MaxBytes, "Maximum bytes of the transactions in the pool")
+	var mempoolExpiry = flag.Int64("mempoolExpiry", blockchain.DefaultMempoolConfig().Expiry, "Seconds a transaction can wait in the pool")
+	var consensus = flag.String("consensus", blockchain.CONSENSUS_POW, "Consensus engine (pow, instant, interval), instant and interval only in networks that allow unsealed blocks")
+	var sealInterval = flag.Int64("sealInterval", blockchain.DEFAULT_SEAL_INTERVAL, "Seconds between blocks of the interval consensus engine")
+	var miningThreads = flag.Int("miningThreads", runtime.NumCPU(), "Goroutines hashing in the proof of work consensus engine")
+	var mine = flag.Bool("mine", true, "Nodes build and seal blocks, it can be changed per node with /mining")
+	var walletPassword = flag.String("walletPassword", "", "Password encrypting the wallets of the nodes, WALLET_PASSWORD is used if empty, without it the wallets are not stored")
+	var coinSelection = flag.String("coinSelection", wallet.SELECTION_BRANCH_AND_BOUND, "Coin selection of the wallets (bnb, largest)")
+	var dustThreshold = flag.Int("dustThreshold", wallet.DEFAULT_DUST_THRESHOLD, "Change under it is left to the miner instead of creating an output")
 	flag.Parse()
+
+	if env, ok := os.LookupEnv("WALLET_PASSWORD"); ok && *walletPassword == "" {
+		walletPassword = &env
+	}
+
+	http_server.DataDir = *dataDir
+	http_server.Network = *network
+	http_server.Mempool = blockchain.MempoolConfig{MaxCount: *mempoolCount, MaxBytes: *mempoolBytes, Expiry: *mempoolExpiry}
+	http_server.Consensus = *consensus
+	http_server.SealInterval = *sealInterval
+	http_server.MiningThreads = *miningThreads
+	http_server.DisableMining = !*mine
+	http_server.WalletPassword = *walletPassword
+	http_server.CoinSelection = *coinSelection
+	http_server.DustThreshold = *dustThreshold
 	port, ok := os.LookupEnv("SERVER_PORT")
 	if ok {
 		UIPort = &port
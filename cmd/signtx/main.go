@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/wallet"
+)
+
+// Sign transactions offline with the keys of a keystore
+// TO TEST
+// go run . -keystore=treasury.json -newAddress
+// curl -d '{"name":"nodeA","inputs":[...],"outputs":[...]}' localhost:8080/tx/create > unsigned.json
+// go run . -keystore=treasury.json -in=unsigned.json -out=signed.json
+// curl -d '{"name":"nodeA","tx":"<tx of signed.json>"}' localhost:8080/tx/raw
+
+// readUnsigned transaction created by a node
+func readUnsigned(file string) (*blockchain.UnsignedTransaction, *blockchain.TransactionMulti, error) {
+	var content []byte
+	var err error
+	if file == "" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	unsigned := &blockchain.UnsignedTransaction{}
+	if err := json.Unmarshal(content, unsigned); err != nil {
+		return nil, nil, err
+	}
+	tx, err := blockchain.DecodeTransaction(unsigned.Tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the summary is only trusted if the spent values add up
+	if err := unsigned.CheckSpent(tx); err != nil {
+		return nil, nil, err
+	}
+	return unsigned, tx, nil
+}
+
+// printSummary of what is signed so it can be reviewed
+func printSummary(unsigned *blockchain.UnsignedTransaction, tx *blockchain.TransactionMulti) {
+	fmt.Fprintf(os.Stderr, "Signing transaction %v\n", tx.String())
+	for _, spent := range unsigned.Spent {
+		fmt.Fprintf(os.Stderr, "  spends %v from %v\n", spent.Value, spent.Address)
+	}
+	for _, out := range tx.Outputs {
+		fmt.Fprintf(os.Stderr, "  pays   %v to %v\n", out.Value, out.PubKeyHash.String())
+	}
+	fmt.Fprintf(os.Stderr, "  fee    %v\n", tx.Fee)
+}
+
+func main() {
+
+	var keystore = flag.String("keystore", wallet.WALLET_FILE, "Keystore file with the signing keys")
+	var password = flag.String("password", "", "Password of the keystore, WALLET_PASSWORD is used if empty")
+	var newAddress = flag.Bool("newAddress", false, "Create a new address in the keystore and print it")
+	var in = flag.String("in", "", "File with the unsigned transaction returned by /tx/create, stdin if empty")
+	var out = flag.String("out", "", "File where the signed transaction is written, stdout if empty")
+	flag.Parse()
+
+	if env, ok := os.LookupEnv("WALLET_PASSWORD"); ok && *password == "" {
+		password = &env
+	}
+	open := wallet.Open
+	if *newAddress {
+		open = wallet.OpenOrCreate
+	}
+	keys, err := open(*keystore, *password)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *newAddress {
+		address, err := keys.NewAddress()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(address.String())
+		return
+	}
+
+	unsigned, tx, err := readUnsigned(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printSummary(unsigned, tx)
+	if err := keys.Sign(tx, unsigned); err != nil {
+		log.Fatal(err)
+	}
+	if err := tx.VerifySignature(); err != nil {
+		log.Fatal(err)
+	}
+	raw, err := blockchain.EncodeTransaction(tx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	content, _ := json.Marshal(map[string]string{"tx": raw})
+	if *out == "" {
+		fmt.Println(string(content))
+		return
+	}
+	if err := ioutil.WriteFile(*out, content, 0600); err != nil {
+		log.Fatal(err)
+	}
+}
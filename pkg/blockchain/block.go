@@ -4,62 +4,112 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/dedis/protobuf"
 )
 
+// BlockHeader struct
+// MerkleRoot commits to the transactions of the body
+// Target is the highest value the header hash can have
+// Timestamp in seconds when the block was mined
+type BlockHeader struct {
+	PrevHash   [32]byte
+	MerkleRoot utils.HashValue
+	Timestamp  int64
+	Target     utils.HashValue
+	Nonce      uint64
+}
+
+// Hash header, the proof of work and the
+// identity of the block are computed over it
+func (header *BlockHeader) Hash() (out [32]byte) {
+	h := sha256.New()
+	h.Write(header.PrevHash[:])
+	h.Write(header.MerkleRoot[:])
+	binary.Write(h, binary.LittleEndian, header.Timestamp)
+	h.Write(header.Target[:])
+	binary.Write(h, binary.LittleEndian, header.Nonce)
+	copy(out[:], h.Sum(nil))
+	return
+}
+
+// BlockBody struct
+type BlockBody struct {
+	Transactions []TransactionMulti
+}
+
 // Block stuct
 type Block struct {
-	PrevHash     [32]byte
-	Nonce        [32]byte
-	Transactions []Transaction
-	timestamp    string
-	TXCount      int
+	Header BlockHeader
+	Body   BlockBody
 }
 
 // NewBlock func
 func NewBlock(prev [32]byte) *Block {
 	return &Block{
-		PrevHash:     prev,
-		Transactions: []Transaction{},
-		// timestamp: // TODO create timestamp
-		TXCount: 0,
+		Header: BlockHeader{PrevHash: prev},
+		Body:   BlockBody{Transactions: []TransactionMulti{}},
+	}
+}
+
+// AppendTransaction adds the transaction to the body
+// and updates the merkle root of the header
+func (block *Block) AppendTransaction(tx TransactionMulti) {
+	block.Body.Transactions = append(block.Body.Transactions, tx)
+	block.Header.MerkleRoot = block.ComputeMerkleRoot()
+}
+
+// ComputeMerkleRoot of the transactions in the body
+func (block *Block) ComputeMerkleRoot() utils.HashValue {
+	return ComputeMerkleRoot(block.getTransactionNames())
+}
+
+// GetMerkleProof proves that the transaction is included in the block
+func (block *Block) GetMerkleProof(txName utils.HashValue) (*MerkleProof, bool) {
+	names := block.getTransactionNames()
+	for index, name := range names {
+		if name == txName {
+			return BuildMerkleProof(names, index), true
+		}
 	}
+	return nil, false
 }
 
-// AppendTransaction func
-func (block *Block) AppendTransaction(tx Transaction) {
-	block.Transactions = append(block.Transactions, tx)
-	block.TXCount = len(block.Transactions)
+func (block *Block) getTransactionNames() []utils.HashValue {
+	names := make([]utils.HashValue, len(block.Body.Transactions))
+	for index, tx := range block.Body.Transactions {
+		names[index] = tx.Name
+	}
+	return names
 }
 
-// AppendTransaction func
+// GetSize returns the bytes of the encoded block
+func (block *Block) GetSize() int {
+	packet, err := protobuf.Encode(block)
+	if err != nil {
+		return 0
+	}
+	return len(packet)
+}
+
+// String hex of the header hash
 func (block *Block) String() string {
-	return hex.EncodeToString(block.Nonce[:])
+	hash := block.Hash()
+	return hex.EncodeToString(hash[:])
 }
 
 // PrintPrev func
 func (block *Block) PrintPrev() string {
-	return hex.EncodeToString(block.PrevHash[:])
+	return hex.EncodeToString(block.Header.PrevHash[:])
 }
 
 // IsNextBlock func
 func (block *Block) IsNextBlock(newBlock *Block) bool {
-	if hex.EncodeToString(newBlock.PrevHash[:]) == hex.EncodeToString(block.Nonce[:]) {
-		return true
-	}
-	return false
+	return newBlock.Header.PrevHash == block.Hash()
 }
 
-// Hash block
-func (block *Block) Hash() (out [32]byte) {
-	h := sha256.New()
-	h.Write(block.PrevHash[:])
-	h.Write(block.Nonce[:])
-	h.Write([]byte(block.timestamp))
-	binary.Write(h, binary.LittleEndian, uint32(len(block.Transactions)))
-	for _, t := range block.Transactions {
-		th := t.Name
-		h.Write(th[:])
-	}
-	copy(out[:], h.Sum(nil))
-	return
+// Hash block, only the header is hashed
+func (block *Block) Hash() [32]byte {
+	return block.Header.Hash()
 }
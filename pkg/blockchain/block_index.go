@@ -0,0 +1,281 @@
+package blockchain
+
+import (
+	"math/big"
+	"sync"
+)
+
+// MAX_ORPHAN_BLOCKS kept waiting for their parent
+const MAX_ORPHAN_BLOCKS = 500
+
+// BlockNode struct
+// Entry of the block tree, Work is the
+// cumulative work from the genesis to the block
+type BlockNode struct {
+	Block    *Block
+	Hash     string
+	Parent   *BlockNode
+	Children []*BlockNode
+	Height   int
+	Work     *big.Int
+	Invalid  bool
+}
+
+// BlockIndex struct
+// Tree of every block connected to the genesis,
+// blocks whose parent is unknown are kept as orphans
+// until the parent arrives
+type BlockIndex struct {
+	nodes    map[string]*BlockNode
+	orphans  map[string]*Block
+	waiting  map[string][]string // parent -> orphans
+	genesis  *BlockNode
+	bestNode *BlockNode
+	mux      sync.Mutex
+}
+
+// NewBlockIndex func
+func NewBlockIndex(genesis *Block) *BlockIndex {
+	root := &BlockNode{
+		Block:    genesis,
+		Hash:     genesis.String(),
+		Children: []*BlockNode{},
+		Work:     GetBlockWork(genesis.Header.Target),
+	}
+	return &BlockIndex{
+		nodes:    map[string]*BlockNode{root.Hash: root},
+		orphans:  make(map[string]*Block),
+		waiting:  make(map[string][]string),
+		genesis:  root,
+		bestNode: root,
+	}
+}
+
+// Add the block to the tree, returns the nodes connected by it,
+// which include the orphans that were waiting for the block
+func (index *BlockIndex) Add(block *Block) (connected []*BlockNode, orphan bool) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	hash := block.String()
+	if index.nodes[hash] != nil || index.orphans[hash] != nil {
+		return nil, false
+	}
+	parent, ok := index.nodes[block.PrintPrev()]
+	if !ok {
+		index.addOrphan(hash, block)
+		return nil, true
+	}
+	pending := []*Block{block}
+	for len(pending) > 0 {
+		next := pending[0]
+		pending = pending[1:]
+		node := index.connect(parent, next)
+		connected = append(connected, node)
+		for _, orphanHash := range index.waiting[node.Hash] {
+			if orphanBlock, ok := index.orphans[orphanHash]; ok {
+				delete(index.orphans, orphanHash)
+				pending = append(pending, orphanBlock)
+			}
+		}
+		delete(index.waiting, node.Hash)
+		if len(pending) > 0 {
+			parent = index.nodes[pending[0].PrintPrev()]
+		}
+	}
+	return connected, false
+}
+
+// Get the node of the block
+func (index *BlockIndex) Get(hash string) (*BlockNode, bool) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	node, ok := index.nodes[hash]
+	return node, ok
+}
+
+// GetBlock connected or orphan
+func (index *BlockIndex) GetBlock(hash string) (*Block, bool) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	if node, ok := index.nodes[hash]; ok {
+		return node.Block, true
+	}
+	block, ok := index.orphans[hash]
+	return block, ok
+}
+
+// Has check if the block is connected or orphan
+func (index *BlockIndex) Has(hash string) bool {
+	_, ok := index.GetBlock(hash)
+	return ok
+}
+
+// GetBestTip returns the valid node with most cumulative work
+func (index *BlockIndex) GetBestTip() *BlockNode {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	return index.bestNode
+}
+
+// MarkInvalid marks the node and its descendants
+// as invalid so they are not chosen as best tip
+func (index *BlockIndex) MarkInvalid(node *BlockNode) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	pending := []*BlockNode{node}
+	for len(pending) > 0 {
+		next := pending[0]
+		pending = pending[1:]
+		next.Invalid = true
+		pending = append(pending, next.Children...)
+	}
+	index.bestNode = index.genesis
+	for _, candidate := range index.nodes {
+		index.updateBest(candidate)
+	}
+}
+
+// Remove the node from the tree, its block is not valid as received
+// but the header can still be valid with another body, the descendants
+// wait again as orphans until the block arrives
+func (index *BlockIndex) Remove(node *BlockNode) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	if node == index.genesis || index.nodes[node.Hash] != node {
+		return
+	}
+	siblings := node.Parent.Children
+	for i, sibling := range siblings {
+		if sibling == node {
+			node.Parent.Children = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	delete(index.nodes, node.Hash)
+	pending := append([]*BlockNode{}, node.Children...)
+	for len(pending) > 0 {
+		next := pending[0]
+		pending = pending[1:]
+		delete(index.nodes, next.Hash)
+		index.addOrphan(next.Hash, next.Block)
+		pending = append(pending, next.Children...)
+	}
+	index.bestNode = index.genesis
+	for _, candidate := range index.nodes {
+		index.updateBest(candidate)
+	}
+}
+
+// GetMissingParents of the orphan blocks
+func (index *BlockIndex) GetMissingParents() []string {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	missing := []string{}
+	for parent := range index.waiting {
+		if index.orphans[parent] == nil {
+			missing = append(missing, parent)
+		}
+	}
+	return missing
+}
+
+// Size returns the number of connected and orphan blocks
+func (index *BlockIndex) Size() (connected, orphans int) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	return len(index.nodes), len(index.orphans)
+}
+
+// GetNodes returns every block connected to the genesis
+func (index *BlockIndex) GetNodes() []*BlockNode {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	nodes := []*BlockNode{}
+	for _, node := range index.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// GetOrphans returns the blocks waiting for their parent
+func (index *BlockIndex) GetOrphans() []*Block {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	orphans := []*Block{}
+	for _, block := range index.orphans {
+		orphans = append(orphans, block)
+	}
+	return orphans
+}
+
+// FindFork returns the last common ancestor of two nodes
+func FindFork(node, other *BlockNode) *BlockNode {
+	for node.Height > other.Height {
+		node = node.Parent
+	}
+	for other.Height > node.Height {
+		other = other.Parent
+	}
+	for node != other {
+		node, other = node.Parent, other.Parent
+	}
+	return node
+}
+
+// GetPath returns the nodes after the ancestor up to the node
+func GetPath(ancestor, node *BlockNode) []*BlockNode {
+	path := make([]*BlockNode, node.Height-ancestor.Height)
+	for ; node != ancestor; node = node.Parent {
+		path[node.Height-ancestor.Height-1] = node
+	}
+	return path
+}
+
+func (index *BlockIndex) connect(parent *BlockNode, block *Block) *BlockNode {
+	node := &BlockNode{
+		Block:    block,
+		Hash:     block.String(),
+		Parent:   parent,
+		Children: []*BlockNode{},
+		Height:   parent.Height + 1,
+		Work:     new(big.Int).Add(parent.Work, GetBlockWork(block.Header.Target)),
+		Invalid:  parent.Invalid,
+	}
+	parent.Children = append(parent.Children, node)
+	index.nodes[node.Hash] = node
+	index.updateBest(node)
+	return node
+}
+
+func (index *BlockIndex) updateBest(node *BlockNode) {
+	if !node.Invalid && isBetterChain(node.Work, node.Hash, index.bestNode.Work, index.bestNode.Hash) {
+		index.bestNode = node
+	}
+}
+
+func (index *BlockIndex) addOrphan(hash string, block *Block) {
+	if len(index.orphans) >= MAX_ORPHAN_BLOCKS {
+		for evicted, evictedBlock := range index.orphans {
+			index.removeWaiting(evictedBlock.PrintPrev(), evicted)
+			delete(index.orphans, evicted)
+			break
+		}
+	}
+	index.orphans[hash] = block
+	index.waiting[block.PrintPrev()] = append(index.waiting[block.PrintPrev()], hash)
+}
+
+func (index *BlockIndex) removeWaiting(parent, hash string) {
+	waiting := index.waiting[parent]
+	for i, orphanHash := range waiting {
+		if orphanHash == hash {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	if len(waiting) == 0 {
+		delete(index.waiting, parent)
+	} else {
+		index.waiting[parent] = waiting
+	}
+}
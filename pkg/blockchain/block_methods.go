@@ -1,138 +1,179 @@
 package blockchain
 
 import (
-	"encoding/hex"
+	"errors"
+	"fmt"
 
 	"github.com/ageapps/gambercoin/pkg/logger"
+	"github.com/ageapps/gambercoin/pkg/utils"
 )
 
 // isBlockValid to the blockchain
 func (bc *BlockChain) isBlockValid(bl *Block) bool {
-	if !checkZeros(bl.Nonce) {
+	if err := bc.engine.VerifySeal(bl); err != nil {
+		logger.Logv("Block %v rejected: %v", bl.String(), err)
 		return false
 	}
-	return !(bc.getBlockType(bl) == BLOCK_OLD)
+	if err := checkFutureTimestamp(bl, bc.clock.Now()); err != nil {
+		logger.Logw("Block %v rejected: %v", bl.String(), err)
+		return false
+	}
+	if bl.Header.PrevHash == [32]byte{} {
+		// only our own genesis has no parent
+		return false
+	}
+	if bc.index.Has(bl.String()) {
+		logger.Logv("Block already known")
+		return false
+	}
+	return true
 }
 
-// checkZeros
-func checkZeros(nonce [32]byte) bool {
-	prefix := nonce[0:NumberOfZeros]
-	flag := true
-	for _, num := range prefix {
-		flag = flag && int(num) == 0
-	}
-	return flag
+// checkBlockBody checks the size of the block and that the transactions
+// match the merkle root of the header, repeating the last transactions
+// of a level keeps the same root so repeated transactions are rejected
+func checkBlockBody(block *Block) error {
+	if size := block.GetSize(); size > MaxBlockSize {
+		return fmt.Errorf("block of %v bytes is too big", size)
+	}
+	if block.Header.MerkleRoot != block.ComputeMerkleRoot() {
+		return errors.New("merkle root does not match the transactions")
+	}
+	names := make(map[utils.HashValue]bool)
+	for _, tx := range block.Body.Transactions {
+		if names[tx.Name] {
+			return fmt.Errorf("transaction %v is repeated", tx.String())
+		}
+		names[tx.Name] = true
+	}
+	return nil
 }
 
-func (bc *BlockChain) getBlockType(newBlock *Block) string {
-	// same prev hash than out prev block
+// validateNextBlock checks the block that extends the canonical
+// chain against the chain state, invalid is true only when the header
+// breaks the rules, errors of the body can come from a peer that changed
+// it and a timestamp in the future can be valid later
+func (bc *BlockChain) validateNextBlock(newBlock *Block) (invalid bool, err error) {
+	if err := bc.engine.VerifySeal(newBlock); err != nil {
+		return true, err
+	}
+	if err := checkBlockBody(newBlock); err != nil {
+		return false, err
+	}
 	canonicalChain := bc.getCanonicalChain()
-	if canonicalChain.isNextBlockInChain(newBlock) {
-		return BLOCK_CURRENT
+	if !canonicalChain.isNextBlockInChain(newBlock) {
+		return false, errors.New("block does not extend the canonical chain")
 	}
-	_, ok := bc.getBlockPool()[newBlock.String()]
-	if ok {
-		logger.Logv("Block already in pool")
-		return BLOCK_OLD
+	if err := checkFutureTimestamp(newBlock, bc.clock.Now()); err != nil {
+		return false, err
 	}
-
-	if existing := bc.isBlockInCanonicalChain(newBlock); existing {
-		logger.Logv("Block already in chain")
-		return BLOCK_OLD
+	if err := checkBlockTimestamp(&canonicalChain, newBlock, bc.clock.Now()); err != nil {
+		return true, err
 	}
-
-	return BLOCK_UNKOWN_PARENT
-}
-
-func (bc *BlockChain) isBlockInCanonicalChain(newBlock *Block) bool {
-	for _, block := range bc.getCanonicalChain().Blocks {
-		if block.String() == newBlock.String() {
-			return true
-		}
+	if err := bc.engine.VerifyBlock(&canonicalChain, newBlock); err != nil {
+		return true, err
 	}
-	return false
+	// signatures are not part of the transaction names,
+	// a peer can change them keeping the merkle root
+	return false, bc.verifyBlockTransactions(newBlock, canonicalChain.size(), getMedianTimePast(&canonicalChain))
 }
 
-func (bc *BlockChain) addBlock(newBlock *Block, forking bool) (added bool) {
-	if !checkZeros(newBlock.Nonce) {
+// addBlock adds the block to the block index and moves
+// the canonical chain to the valid tip with most work
+func (bc *BlockChain) addBlock(newBlock *Block) (added bool) {
+	if bc.engine.VerifySeal(newBlock) != nil {
 		return false
 	}
-	blockType := bc.getBlockType(newBlock)
-	logger.Logb("Adding Block of type %v", blockType)
-
-	switch blockType {
-
-	case BLOCK_CURRENT:
-		// stop mining
+	// a body not matching the header is not indexed,
+	// the block with the right body can still arrive
+	if err := checkBlockBody(newBlock); err != nil {
+		logger.Logw("Block %v rejected: %v", newBlock.String(), err)
+		return false
+	}
+	connected, orphan := bc.index.Add(newBlock)
+	if !orphan && len(connected) == 0 {
+		logger.Logf("Block already in blockchain, dropping it...")
+		return false
+	}
+	// blocks are stored once they join the canonical chain, a copy with
+	// changed signatures would otherwise take the place of the valid body
+	if orphan {
+		logger.Logf("Block %v waiting for parent %v", newBlock.String(), newBlock.PrintPrev())
+		return true
+	}
+	logger.Logb("Block %v connected %v blocks to the index", newBlock.String(), len(connected))
+	if bc.updateCanonicalChain() {
+		// the tip changed, mine on top of it
 		bc.setMining(false)
-		bc.addToBlockChain(newBlock)
-		if !bc.checkTransactionsInCurrentBlock(newBlock) {
-			logger.Logf("Transactions NOT found in current block")
-			// there is no transactions in the block that i am currently minig,
-			// jclean transaction pool from new block and keep mining
-			bc.cleanTransactionPoolByAddedBlock(newBlock)
-			if !forking {
-				bc.buildBlockAndMine()
-			}
-		} else {
-			logger.Logf("Transactions found in current block")
-			// fmt.Println(newBlock.Transactions)
-			// fmt.Println(bc.getCurrentBlock().Transactions)
+		bc.buildBlockAndMine()
+	} else {
+		logger.LogForkShort(newBlock.String())
+	}
+	return true
+}
 
-			// there is transactions in the currentBlock,
-			// add them again to the Txpool, clean transaction pool from new block,
-			// reset current block and rebuild it to mine again
-			bc.addBlockTransactionsToPool(bc.getCurrentBlock())
-			bc.cleanTransactionPoolByAddedBlock(newBlock)
-			bc.resetCurrentBlock()
-			if !forking {
-				bc.buildBlockAndMine()
-			}
+// updateCanonicalChain moves the canonical chain to the best tip
+// of the index rolling back to the fork point and applying the new
+// branch, blocks that fail validation are marked as invalid
+// and the next best tip is tried
+func (bc *BlockChain) updateCanonicalChain() (changed bool) {
+	rolledBack := false
+	oldTip, _ := bc.index.Get(bc.getTipName())
+	forkHeight := oldTip.Height
+	returned := []string{}
+	for {
+		best := bc.index.GetBestTip()
+		tip, _ := bc.index.Get(bc.getTipName())
+		if best == tip {
+			break
 		}
-
-		return true
-	case BLOCK_UNKOWN_PARENT:
-		if forking {
-			logger.Logw("SOMETHING IS WRONG, UNKOWN PARENT FOUND WHILE FORKING")
+		fork := FindFork(tip, best)
+		if fork != tip {
+			logger.LogForkLong(tip.Height - fork.Height)
+			returned = append(returned, bc.rollbackCanonicalChain(fork)...)
+			rolledBack = true
+			if fork.Height < forkHeight {
+				forkHeight = fork.Height
+			}
 		}
-		// just save it
-		sideChainIndex, parentIndex := bc.addToBlockPool(newBlock)
-		if sideChainIndex >= 0 && parentIndex >= 0 {
-			logger.Logf("Side chains found")
-			bc.setMining(false)
-			bc.forkCanonicalChain(sideChainIndex, parentIndex)
-			bc.buildBlockAndMine()
-		} else {
-			logger.Logf("NO Side chains found")
+		for _, node := range GetPath(fork, best) {
+			if invalid, err := bc.validateNextBlock(node.Block); err != nil {
+				logger.Logw("Block %v rejected: %v", node.Hash, err)
+				if invalid {
+					bc.index.MarkInvalid(node)
+				} else {
+					bc.index.Remove(node)
+				}
+				break
+			}
+			// track before the block spends the outputs of its transactions
+			bc.trackBlock(node.Block, node.Height, TX_CONFIRMED, "")
+			bc.addToBlockChain(node.Block)
+			bc.cleanTransactionPoolByAddedBlock(node.Block)
+			changed = true
 		}
-
-		return true
-	case BLOCK_OLD:
-		logger.Logf("Block already in blockchain, dropping it...")
-		// if it's an old newBlock, just discard it
-		return false
 	}
-	return false
-}
-
-// addToBlockPool adds block to pool
-// and return references to a big sideChain
-func (bc *BlockChain) addToBlockPool(block *Block) (sideChainIndex, parentIndex int) {
-	logger.Logf("Adding Block to Blok Pool - %v", block.String())
-	bc.Lock()
-	bc.blockPool[block.String()] = block
-	bc.Unlock()
-	// everytime a block is added to the block pool
-	// explore and build sidechains
-	bc.buildSideChains(block)
-	return bc.checkLongestChain()
+	if rolledBack {
+		// returned transactions can conflict with the new chain
+		bc.revalidateTransactionPool()
+		bc.addReorg(oldTip, forkHeight, returned)
+	}
+	return changed
 }
 
-func (bc *BlockChain) findNextBlock(block *Block) *Block {
-	for _, newBlock := range bc.getBlockPool() {
-		if hex.EncodeToString(newBlock.PrevHash[:]) == hex.EncodeToString(block.Nonce[:]) {
-			return newBlock
-		}
+// rollbackCanonicalChain undoes the canonical blocks after
+// the fork node and returns their transactions to the pool,
+// the names of the returned transactions are returned
+func (bc *BlockChain) rollbackCanonicalChain(fork *BlockNode) []string {
+	returned := []string{}
+	canonicalChain := bc.getCanonicalChain()
+	for index := canonicalChain.size() - 1; index > fork.Height; index-- {
+		block := canonicalChain.Blocks[index]
+		bc.utxoSet.RollbackBlock(block)
+		bc.trackBlock(block, index, TX_REORGED, fmt.Sprintf("block %v rolled back", block.String()))
+		returned = append(returned, bc.addBlockTransactionsToPool(*block)...)
 	}
-	return nil
+	bc.restoreCanonicalChain(*canonicalChain.getSubchain(0, fork.Height+1))
+	bc.setPrevHash(fork.Block.Hash())
+	return returned
 }
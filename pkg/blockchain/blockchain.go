@@ -1,86 +1,133 @@
 package blockchain
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ageapps/gambercoin/pkg/logger"
 	"github.com/ageapps/gambercoin/pkg/utils"
 )
 
 const (
-	// NumberOfZeros in the nonce
-	NumberOfZeros = 2
-	// BLOCK_OLD const
-	BLOCK_OLD = "BLOCK_OLD"
-	// BLOCK_CURRENT const
-	BLOCK_CURRENT = "BLOCK_CURRENT"
-	// BLOCK_UNKOWN_PARENT const
-	BLOCK_UNKOWN_PARENT = "BLOCK_NEW"
+	// MEMPOOL_EXPIRY_PERIOD in seconds between expiry checks of the pool
+	MEMPOOL_EXPIRY_PERIOD = 60
 )
 
 // BlockChain struct
 type BlockChain struct {
-	sendChannel    chan<- ChainMessage //  channel to send messages to node
-	ReceiveChannel chan ChainMessage   // write-only channel to receive messages from node
+	sendChannel    chan ChainMessage //  channel to send messages to node
+	ReceiveChannel chan ChainMessage // write-only channel to receive messages from node
 
 	minig       bool
+	mineEnabled bool
+	miningRound uint64
 	active      bool //   monguer handler active state
-	blockTime   uint64
+	stats       miningSample
 	nodeAddress string
 	minerHash   utils.HashValue
+	params      *NetworkParams
+	genesis     *Block
+	engine      ConsensusEngine
 
 	canonicalChain Chain
-	sideChains     []*Chain
+	utxoSet        *UTXOSet
 	currentBlock   Block
 	prevHash       utils.HashValue
-
-	tansactionPool map[string]*Transaction
-	blockPool      map[string]*Block
-
+	// txHeights of the canonical transactions by name
+	txHeights map[string]int
+	addresses *addressIndex
+
+	mempool *Mempool
+	tracker *txTracker
+	index   *BlockIndex
+	store   BlockStore
+	clock   *NetworkClock
+	// restoring while the stored blocks are replayed,
+	// nothing is written to the store until it finishes
+	restoring bool
+
+	reorgs      []Reorg
+	mineChannel chan bool
 	quitChannel chan bool
 
 	sync.Mutex
 }
 
-// NewBlockChain func
-func NewBlockChain(nodeAddress string, minerHash utils.HashValue) *BlockChain {
-	return &BlockChain{
+// NewBlockChain creates the blockchain starting from the genesis of the
+// network, blocks are sealed and verified by the consensus engine
+// and the state saved in the store is restored
+func NewBlockChain(nodeAddress string, minerHash utils.HashValue, store BlockStore, params *NetworkParams, mempool MempoolConfig, engine ConsensusEngine) (*BlockChain, error) {
+	genesis, err := params.GenesisBlock()
+	if err != nil {
+		return nil, err
+	}
+	if engine.Name() != CONSENSUS_POW && !params.AllowUnsealed {
+		return nil, fmt.Errorf("consensus engine %v not allowed in network %v", engine.Name(), params.NetworkID)
+	}
+	bc := &BlockChain{
 		minig:       false,
+		mineEnabled: true,
 		active:      false,
 		nodeAddress: nodeAddress,
 		minerHash:   minerHash,
+		params:      params,
+		genesis:     genesis,
+		engine:      engine,
 
 		canonicalChain: NewEmptyChain(),
-		sideChains:     []*Chain{},
+		txHeights:      make(map[string]int),
+		addresses:      newAddressIndex(),
+		utxoSet:        NewUTXOSet(),
 		prevHash:       [32]byte{},
 
-		tansactionPool: make(map[string]*Transaction),
-		blockPool:      make(map[string]*Block),
+		mempool: NewMempool(mempool),
+		tracker: newTxTracker(),
+		index:   NewBlockIndex(genesis),
+		store:   store,
+		clock:   NewNetworkClock(),
 
+		reorgs:      []Reorg{},
+		mineChannel: make(chan bool, 1),
 		quitChannel: make(chan bool),
 	}
-
+	bc.restoreFromStore()
+	return bc, nil
 }
 
 // Start blockchain process
 func (bc *BlockChain) Start(onStopHandler func()) <-chan ChainMessage {
 	bc.sendChannel = make(chan ChainMessage)
 	bc.ReceiveChannel = make(chan ChainMessage)
+	bc.setActive(true)
+	expiryTicker := time.NewTicker(time.Duration(MEMPOOL_EXPIRY_PERIOD) * time.Second)
 	go func() {
+		if bc.engine.SealEmptyBlocks() {
+			bc.buildBlockAndMine()
+		}
 		for {
 			select {
+			case <-expiryTicker.C:
+				bc.expireTransactionPool()
+				// retry the transactions that were not mature
+				bc.buildBlockAndMine()
+
+			case <-bc.mineChannel:
+				bc.buildBlockAndMine()
+
 			case message := <-bc.ReceiveChannel:
 				if message.IsTx() {
 					tx := message.Tx
-					bc.processTransaction(tx)
+					bc.processTransaction(tx, message.Result)
 				} else if message.IsBlock() {
 					bl := message.Block
-					bc.processBlock(bl)
+					bc.processBlock(bl, message.Origin)
 				} else {
 					logger.Logw("Message received not recognized")
 				}
 
 			case <-bc.quitChannel:
+				expiryTicker.Stop()
 				bc.setMining(false)
 				logger.Logf("Finishing Blockchain")
 				close(bc.sendChannel)
@@ -89,189 +136,154 @@ func (bc *BlockChain) Start(onStopHandler func()) <-chan ChainMessage {
 			}
 		}
 	}()
-	return bc.ReceiveChannel
+	return bc.sendChannel
 }
 
-func (bc *BlockChain) processTransaction(tx *Transaction) {
-	if !bc.isTransactionValid(tx) {
-		return
+func (bc *BlockChain) processTransaction(tx *TransactionMulti, result chan error) {
+	err := bc.poolTransaction(tx)
+	if result != nil {
+		result <- err
 	}
-	bc.addToTransactionPool(tx)
-	if !bc.isMining() {
+	if err == nil && !bc.isMining() {
 		bc.buildBlockAndMine()
 	}
 }
 
-func (bc *BlockChain) processBlock(bl *Block) {
+// poolTransaction verifies the transaction and adds it to the pool
+func (bc *BlockChain) poolTransaction(tx *TransactionMulti) error {
+	if bc.isTransactionKnown(tx) {
+		return fmt.Errorf("transaction %v already known", tx.String())
+	}
+	if err := bc.verifyPoolTransaction(tx); err != nil {
+		logger.Logw("Transaction %v rejected: %v", tx.String(), err)
+		bc.rejectTransaction(tx, err.Error())
+		return err
+	}
+	if err := bc.addToTransactionPool(tx); err != nil {
+		logger.Logw("Transaction %v rejected: %v", tx.String(), err)
+		bc.rejectTransaction(tx, err.Error())
+		return err
+	}
+	bc.trackTransaction(tx, TX_PENDING, "", nil, -1)
+	return nil
+}
+
+func (bc *BlockChain) processBlock(bl *Block, origin string) {
 	if !bc.isBlockValid(bl) {
 		return
 	}
-	bc.addBlock(bl, false)
+	if bc.addBlock(bl) && origin == bc.nodeAddress {
+		// block mined by this node, publish it
+		bc.sendBlock(bl)
+	}
 }
 
 func (bc *BlockChain) buildBlockAndMine() {
 	canonicalChain := bc.getCanonicalChain()
-	for _, block := range bc.getBlockPool() {
-		if canonicalChain.isNextBlockInChain(block) {
-			logger.Logf("Found stored block matching prev - %v", block.String())
-			bc.processBlock(block)
-			return
-		}
-	}
 	// Check mining and transactions available
-	if len(bc.getTransactionPool()) > 0 && !bc.isMining() {
+	if (bc.mempool.Count() > 0 || bc.engine.SealEmptyBlocks()) && bc.isMiningEnabled() && !bc.isMining() {
 		// -> Build new block from prevhash
 		currentBlock := *NewBlock(bc.getPrevHash())
-		// -> Add coinbase to block
-		coinBase := Transaction{
-			Input:  [32]byte{},
-			Output: bc.minerHash,
-			Amount: uint32(1),
+		currentBlock.Header.Target = bc.engine.GetTarget(&canonicalChain)
+		currentBlock.Header.Timestamp = getNextTimestamp(&canonicalChain, bc.clock.Now())
+		// -> Pick the most profitable transactions that fit in the block
+		height := canonicalChain.size()
+		minerHash := bc.GetMinerHash()
+		reward := bc.params.GetBlockReward(height)
+		emptyBlock := currentBlock
+		emptyBlock.AppendTransaction(newMinerCoinbase(minerHash, reward, height))
+		// leave room for the fees in the coinbase value
+		selected := bc.mempool.SelectForBlock(MaxBlockSize-emptyBlock.GetSize()-2*txFraming, height, getMedianTimePast(&canonicalChain))
+		if len(selected) == 0 && !bc.engine.SealEmptyBlocks() {
+			// locks only mature as the chain grows, hashing
+			// engines keep extending it while transactions wait
+			if _, hashing := bc.engine.(HashingEngine); !hashing {
+				logger.Logf("Transactions in pool are not mature yet")
+				return
+			}
+			logger.Logf("Mining without the transactions in pool until they mature")
+		}
+		fees := 0
+		for _, tx := range selected {
+			fees += tx.Fee
 		}
-		currentBlock.AppendTransaction(coinBase)
+		// -> Add coinbase with the reward and the fees to block
+		currentBlock.AppendTransaction(newMinerCoinbase(minerHash, reward+fees, height))
 		// -> Fill block with transactions from pool
-		for _, tx := range bc.getTransactionPool() {
+		for _, tx := range selected {
 			currentBlock.AppendTransaction(*tx)
 		}
 		// -> Set as Current block
 		bc.setCurrentBlock(currentBlock)
-		logger.Logf("Mining current block with transactions %v", len(currentBlock.Transactions))
+		logger.Logf("Mining current block with transactions %v", len(currentBlock.Body.Transactions))
 		// Mine it
+		round := bc.startMining()
 		go func() {
-			bc.mine()
+			bc.mine(round, canonicalChain)
 		}()
 	} else {
 		logger.Logf("No transactions to mine/ already minig...")
 	}
 }
 
-func (bc *BlockChain) mine() {
-	bc.setMining(true)
+// mine seals the current block with the consensus engine until
+// it is sealed or a new mining round starts on another tip
+func (bc *BlockChain) mine(round uint64, chain Chain) {
 	currentBlock := bc.getCurrentBlock()
-	prev := bc.getPrevHash()
-	logger.Logf("Expecting - %v", prev.String())
-	logger.Logf("Mining block with parent - %v", currentBlock.PrintPrev())
-	init := getTimestamp()
-	for bc.isMining() {
-		bc.resetCurrentBlock()
-		nonce := currentBlock.Hash()
-		currentBlock.Nonce = nonce
-		if checkZeros(nonce) {
-			bc.setBlockTime(uint64(getTimestamp() - init))
-			logger.LogFoundBlock(currentBlock.String())
-			// Send block to main routine to process it
-			bc.sendBlockToProcess(&currentBlock)
-			break
-		}
+	logger.Logf("Mining block with parent - %v with %v", currentBlock.PrintPrev(), bc.engine.Name())
+	bc.startMiningStats()
+	abort := func() bool {
+		return !bc.isMiningRound(round)
+	}
+	sealed := bc.engine.Seal(&chain, &currentBlock, abort)
+	bc.finishMiningStats(sealed)
+	if sealed {
+		logger.LogFoundBlock(currentBlock.String())
+		// Send block to main routine to process it
+		bc.sendBlockToProcess(&currentBlock)
 	}
 	logger.Logf("FINISHED MINIG")
-	bc.setMining(false)
+	bc.stopMining(round)
 }
 
-func (bc *BlockChain) addBlockTransactionsToPool(newBlock Block) {
+// addBlockTransactionsToPool returns the names of the transactions added
+func (bc *BlockChain) addBlockTransactionsToPool(newBlock Block) []string {
+	added := []string{}
 	// Look in tx pool
-	for _, newTx := range newBlock.Transactions {
-		bc.addToTransactionPool(&newTx)
-	}
-}
-
-func (bc *BlockChain) resetCurrentBlock() {
-	bc.setCurrentBlock(*NewBlock(bc.getPrevHash()))
-}
-
-// check if new block contains transactions that i'm currently mining
-func (bc *BlockChain) checkTransactionsInCurrentBlock(newBlock *Block) bool {
-	cb := bc.getCurrentBlock()
-	// Look in current block and non coincident tx, add to tx pool
-	for _, newTx := range newBlock.Transactions {
-		for _, tx := range cb.Transactions {
-			if newTx.String() == tx.String() {
-				return true
-			}
+	for index := range newBlock.Body.Transactions {
+		newTx := newBlock.Body.Transactions[index]
+		if newTx.IsCoinbase() || bc.conflictsWithPool(&newTx) {
+			continue
+		}
+		if err := bc.addToTransactionPool(&newTx); err == nil {
+			added = append(added, newTx.String())
 		}
 	}
-	return false
+	return added
 }
 
 func (bc *BlockChain) addToBlockChain(block *Block) {
 	// reference the prev hash to the new added block
-	bc.setPrevHash(block.Nonce)
+	bc.setPrevHash(block.Hash())
 
+	// owners of the spent outputs are known until the block is applied
+	chain := bc.getCanonicalChain()
+	entries := bc.getAddressEntries(block, chain.size())
 	bc.Lock()
 	logger.Logf("Adding Block to Canonical Chain - %v", block.String())
 	logger.Logf("With prev - %v", block.PrintPrev())
 	bc.canonicalChain.appendBlock(block)
+	bc.indexTransactions(block, bc.canonicalChain.size()-1)
 	bc.Unlock()
-	bc.logChain()
-}
-
-// build all sidechains possible
-func (bc *BlockChain) buildSideChains(block *Block) {
-	bc.resetSideChains()
-	logger.Logf("Trying to build new sidechains...")
-	for _, block := range bc.getBlockPool() {
-		newChain := NewEmptyChain()
-		lastBlock := block
-		for lastBlock != nil {
-			newChain.appendBlock(lastBlock)
-			lastBlock = bc.findNextBlock(lastBlock)
-		}
-		if newChain.size() > 1 {
-			logger.Logf("Found sidechain of size - %v", newChain.size())
-			bc.addSideChain(&newChain)
+	bc.addresses.add(entries)
+	bc.utxoSet.ApplyBlock(block)
+	if !bc.restoring {
+		if err := bc.store.SaveBlock(block); err != nil {
+			logger.Logw("Error storing block %v: %v", block.String(), err)
 		}
+		bc.saveTip(block)
 	}
-}
-
-func (bc *BlockChain) forkCanonicalChain(sideChainIndex, parentIndex int) {
-	canonicalChain := bc.getCanonicalChain()
-	headCanonicalChain := canonicalChain.getSubchain(0, parentIndex+1)
-	removingChain := canonicalChain.getSubchain(parentIndex+1, canonicalChain.size())
-	logger.LogForkLong(len(removingChain.Blocks))
-
-	// add removed block's transactions to pool
-	for _, block := range removingChain.Blocks {
-		bc.addBlockTransactionsToPool(*block)
-	}
-	// restore canonical chain to head
-	bc.restoreCanonicalChain(*headCanonicalChain)
-
-	sideChain := bc.getSideChains()[sideChainIndex]
-	// add sidechain blocks to blockchain
-	for _, newBlock := range sideChain.Blocks {
-		bc.addBlock(newBlock, true)
-	}
-}
-
-func (bc *BlockChain) checkLongestChain() (sidechain, parentBlock int) {
-	sideChains := bc.getSideChains()
-	canonicalChain := bc.getCanonicalChain()
-	for sideChainIndex := 0; sideChainIndex < len(sideChains); sideChainIndex++ {
-		sideChain := sideChains[sideChainIndex]
-		chainHead := sideChain.Blocks[0]
-		for blockIndex := 0; blockIndex < canonicalChain.size(); blockIndex++ {
-			block := canonicalChain.Blocks[blockIndex]
-			if block.IsNextBlock(chainHead) {
-				logger.Logf("Parent of SideChain found in canonicalChain")
-				// if a block in the canonical chain is the parent
-				// of a head of a chain, lets check the sizes
-				headChainSize := blockIndex + 1
-				subChainSize := canonicalChain.size() - headChainSize
-				// if sidechain is longer, there is a fork,
-				// return the index of the restoring sidechein
-				// and the heah in the blockchain
-				if sideChain.size() > subChainSize {
-					logger.Logf("SideChain of size %v found bigger than canonical %v", sideChain.size(), subChainSize)
-					sidechain = sideChainIndex
-					parentBlock = blockIndex
-					return
-				}
-				logger.LogForkShort(block.String())
-			}
-
-		}
-	}
-	return -1, -1
+	bc.logChain()
 }
 
 // Stop func
@@ -281,23 +293,64 @@ func (bc *BlockChain) Stop() {
 		logger.Logf("Stopping BlockChain handler")
 		bc.setActive(false)
 		close(bc.quitChannel)
+		if err := bc.store.Close(); err != nil {
+			logger.Logw("Error closing block store: %v", err)
+		}
 	} else {
 		logger.Logf("BlockChain already stopped....")
 	}
 }
 
+// GetGenesisHash returns the hash that identifies the network
+func (bc *BlockChain) GetGenesisHash() utils.HashValue {
+	return bc.genesis.Hash()
+}
+
+// GetNetworkParams of the chain
+func (bc *BlockChain) GetNetworkParams() NetworkParams {
+	return *bc.params
+}
+
+// newMinerCoinbase with room for the extra nonce
+func newMinerCoinbase(minerHash utils.HashValue, amount, height int) TransactionMulti {
+	coinbase := NewCoinbase(minerHash, amount, height)
+	coinbase.SetExtraNonce(0)
+	return coinbase
+}
+
+// GetConsensusEngine of the chain
+func (bc *BlockChain) GetConsensusEngine() ConsensusEngine {
+	return bc.engine
+}
+
+// AddPeerTime adjusts the network time with the time reported by a peer
+func (bc *BlockChain) AddPeerTime(peer string, peerTime int64) {
+	bc.clock.AddSample(peer, peerTime)
+}
+
+// GetNetworkTime returns the local time adjusted with the peers offset
+func (bc *BlockChain) GetNetworkTime() int64 {
+	return bc.clock.Now()
+}
+
+// GetBalanceOfHash returns the confirmed coins owned by the hash
 func (bc *BlockChain) GetBalanceOfHash(hash utils.HashValue) int {
-	cc := bc.getCanonicalChain()
-	var balance int
-	for _, bl := range cc.Blocks {
-		for _, tx := range bl.Transactions {
-			if tx.Input.Equals(hash.String()) {
-				balance = balance - int(tx.Amount)
-			}
-			if tx.Output.Equals(hash.String()) {
-				balance = balance + int(tx.Amount)
-			}
+	return bc.utxoSet.GetBalance(hash)
+}
+
+// GetUnspentOutputs returns the confirmed outputs owned by the hash
+func (bc *BlockChain) GetUnspentOutputs(hash utils.HashValue) []UnspentOutput {
+	return bc.utxoSet.GetUnspentOutputs(hash)
+}
+
+// GetSpendableOutputs returns the confirmed outputs owned
+// by the hash that are not spent by a transaction in the pool
+func (bc *BlockChain) GetSpendableOutputs(hash utils.HashValue) []UnspentOutput {
+	spendable := []UnspentOutput{}
+	for _, unspent := range bc.utxoSet.GetUnspentOutputs(hash) {
+		if !bc.isSpentInPool(unspent.PrevOut, unspent.Index) {
+			spendable = append(spendable, unspent)
 		}
 	}
-	return balance
+	return spendable
 }
@@ -20,16 +20,18 @@ func (chain *Chain) size() int {
 	return len(chain.Blocks)
 }
 
-// isNextBlockInChain check if block is the next in the chain
+// isNextBlockInChain check if block is the next in the chain,
+// only a block without parent can start an empty chain
 func (chain *Chain) isNextBlockInChain(newBlock *Block) bool {
 	if len(chain.Blocks) <= 0 {
-		return true
+		return newBlock.Header.PrevHash == [32]byte{}
 	}
 	lastBlock := chain.Blocks[len(chain.Blocks)-1]
 	return lastBlock.IsNextBlock(newBlock)
 }
 
-// getSubchain giben start and end index
+// getSubchain giben start and end index, the blocks are copied so
+// appending to the subchain never writes over a copy of the chain
 func (chain *Chain) getSubchain(start, end int) *Chain {
-	return &Chain{Blocks: chain.Blocks[start:end]}
+	return &Chain{Blocks: append([]*Block{}, chain.Blocks[start:end]...)}
 }
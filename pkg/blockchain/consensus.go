@@ -0,0 +1,165 @@
+package blockchain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// CONSENSUS_POW seals blocks with proof of work
+	CONSENSUS_POW = "pow"
+	// CONSENSUS_INSTANT seals a block as soon as the pool has transactions
+	CONSENSUS_INSTANT = "instant"
+	// CONSENSUS_INTERVAL seals a block every fixed number of seconds
+	CONSENSUS_INTERVAL = "interval"
+	// DEFAULT_SEAL_INTERVAL in seconds between blocks of the interval engine
+	DEFAULT_SEAL_INTERVAL = 10
+	// sealPollPeriod between abort checks of the waiting engines
+	sealPollPeriod = 100 * time.Millisecond
+)
+
+// ConsensusEngine decides how blocks are sealed,
+// how their seal is verified and the difficulty of the chain
+type ConsensusEngine interface {
+	// Name of the engine
+	Name() string
+	// GetTarget expected for the block that extends the chain
+	GetTarget(chain *Chain) utils.HashValue
+	// VerifySeal checks the seal of the block on its own
+	VerifySeal(block *Block) error
+	// VerifyBlock checks the seal of the block against the chain it extends
+	VerifyBlock(chain *Chain, block *Block) error
+	// Seal the block that extends the chain, returns false
+	// if abort returned true before the block was sealed
+	Seal(chain *Chain, block *Block, abort func() bool) bool
+	// SealEmptyBlocks if blocks are produced without transactions in the pool
+	SealEmptyBlocks() bool
+}
+
+// NewConsensusEngine by name, interval is only used by the interval
+// engine and threads by the proof of work
+func NewConsensusEngine(name string, interval int64, threads int) (ConsensusEngine, error) {
+	switch name {
+	case "", CONSENSUS_POW:
+		return NewProofOfWork(threads), nil
+	case CONSENSUS_INSTANT:
+		return &InstantSeal{}, nil
+	case CONSENSUS_INTERVAL:
+		if interval <= 0 {
+			interval = DEFAULT_SEAL_INTERVAL
+		}
+		return &FixedInterval{Interval: interval}, nil
+	}
+	return nil, fmt.Errorf("unknown consensus engine %v", name)
+}
+
+// verifyTarget of the block against the target expected by the engine
+func verifyTarget(engine ConsensusEngine, chain *Chain, block *Block) error {
+	if expected := engine.GetTarget(chain); block.Header.Target != expected {
+		return fmt.Errorf("target %v expected %v", block.Header.Target.String(), expected.String())
+	}
+	return nil
+}
+
+// getLastTarget keeps the target of the last block,
+// used by the engines without difficulty
+func getLastTarget(chain *Chain) utils.HashValue {
+	if chain.size() == 0 {
+		return PowLimit
+	}
+	return chain.Blocks[chain.size()-1].Header.Target
+}
+
+// InstantSeal engine, every block is valid as soon as it is built,
+// used to run nodes and tests without waiting on mining
+type InstantSeal struct{}
+
+// Name func
+func (engine *InstantSeal) Name() string {
+	return CONSENSUS_INSTANT
+}
+
+// GetTarget func
+func (engine *InstantSeal) GetTarget(chain *Chain) utils.HashValue {
+	return getLastTarget(chain)
+}
+
+// VerifySeal func
+func (engine *InstantSeal) VerifySeal(block *Block) error {
+	return nil
+}
+
+// VerifyBlock func
+func (engine *InstantSeal) VerifyBlock(chain *Chain, block *Block) error {
+	return verifyTarget(engine, chain, block)
+}
+
+// Seal func
+func (engine *InstantSeal) Seal(chain *Chain, block *Block, abort func() bool) bool {
+	return !abort()
+}
+
+// SealEmptyBlocks func
+func (engine *InstantSeal) SealEmptyBlocks() bool {
+	return false
+}
+
+// FixedInterval engine, a block is sealed Interval seconds
+// after its parent even if there are no transactions
+type FixedInterval struct {
+	Interval int64
+}
+
+// Name func
+func (engine *FixedInterval) Name() string {
+	return CONSENSUS_INTERVAL
+}
+
+// GetTarget func
+func (engine *FixedInterval) GetTarget(chain *Chain) utils.HashValue {
+	return getLastTarget(chain)
+}
+
+// VerifySeal func
+func (engine *FixedInterval) VerifySeal(block *Block) error {
+	return nil
+}
+
+// VerifyBlock checks the time since the parent
+func (engine *FixedInterval) VerifyBlock(chain *Chain, block *Block) error {
+	if err := verifyTarget(engine, chain, block); err != nil {
+		return err
+	}
+	if chain.size() == 0 {
+		return nil
+	}
+	next := chain.Blocks[chain.size()-1].Header.Timestamp + engine.Interval
+	if block.Header.Timestamp < next {
+		return fmt.Errorf("timestamp %v before the interval, expected %v", block.Header.Timestamp, next)
+	}
+	return nil
+}
+
+// Seal waits until the interval since the parent has passed
+func (engine *FixedInterval) Seal(chain *Chain, block *Block, abort func() bool) bool {
+	if chain.size() > 0 {
+		next := chain.Blocks[chain.size()-1].Header.Timestamp + engine.Interval
+		if block.Header.Timestamp < next {
+			block.Header.Timestamp = next
+		}
+	}
+	for time.Now().Unix() < block.Header.Timestamp {
+		if abort() {
+			return false
+		}
+		time.Sleep(sealPollPeriod)
+	}
+	return !abort()
+}
+
+// SealEmptyBlocks func
+func (engine *FixedInterval) SealEmptyBlocks() bool {
+	return true
+}
@@ -0,0 +1,91 @@
+package blockchain
+
+import (
+	"math/big"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// RetargetInterval number of blocks between difficulty adjustments
+	RetargetInterval = 10
+	// TargetBlockTime expected seconds between blocks
+	TargetBlockTime = 10
+	// MaxRetargetFactor bounds how much the target changes in a retarget
+	MaxRetargetFactor = 4
+)
+
+// PowLimit is the easiest target allowed,
+// a hash needs two leading zero bytes
+var PowLimit = utils.HashValue{
+	0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+}
+
+// MeetsTarget check if the hash is lower or equal than the target
+func MeetsTarget(hash, target utils.HashValue) bool {
+	return hashToInt(hash).Cmp(hashToInt(target)) <= 0
+}
+
+// CalculateNextTarget scales the target by the time it took to mine
+// the last retarget window, the change is bounded by MaxRetargetFactor
+func CalculateNextTarget(target utils.HashValue, firstTimestamp, lastTimestamp int64) utils.HashValue {
+	expected := int64((RetargetInterval - 1) * TargetBlockTime)
+	actual := lastTimestamp - firstTimestamp
+	if actual < expected/MaxRetargetFactor {
+		actual = expected / MaxRetargetFactor
+	}
+	if actual > expected*MaxRetargetFactor {
+		actual = expected * MaxRetargetFactor
+	}
+	next := hashToInt(target)
+	next.Mul(next, big.NewInt(actual))
+	next.Div(next, big.NewInt(expected))
+	if next.Cmp(hashToInt(PowLimit)) > 0 {
+		return PowLimit
+	}
+	return intToHash(next)
+}
+
+// getTargetForChain returns the target expected
+// for the block that extends the chain
+func getTargetForChain(chain *Chain) utils.HashValue {
+	height := chain.size()
+	if height == 0 {
+		return PowLimit
+	}
+	last := chain.Blocks[height-1]
+	if height%RetargetInterval != 0 {
+		return last.Header.Target
+	}
+	first := chain.Blocks[height-RetargetInterval]
+	return CalculateNextTarget(last.Header.Target, first.Header.Timestamp, last.Header.Timestamp)
+}
+
+// GetBlockWork returns the expected number of
+// hashes needed to mine a block with the target
+func GetBlockWork(target utils.HashValue) *big.Int {
+	denominator := new(big.Int).Add(hashToInt(target), big.NewInt(1))
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 256), denominator)
+}
+
+// isBetterChain compares the work of two chains,
+// on equal work the tip with the lowest hash wins
+func isBetterChain(work *big.Int, tip string, otherWork *big.Int, otherTip string) bool {
+	if cmp := work.Cmp(otherWork); cmp != 0 {
+		return cmp > 0
+	}
+	return tip < otherTip
+}
+
+func hashToInt(hash utils.HashValue) *big.Int {
+	return new(big.Int).SetBytes(hash[:])
+}
+
+func intToHash(value *big.Int) (hash utils.HashValue) {
+	bytes := value.Bytes()
+	copy(hash[len(hash)-len(bytes):], bytes)
+	return
+}
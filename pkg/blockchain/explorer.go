@@ -0,0 +1,296 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// MAX_EXPLORER_BLOCKS returned in a page of the canonical chain
+	MAX_EXPLORER_BLOCKS = 100
+)
+
+// InputView of a transaction input with hex hashes
+type InputView struct {
+	PrevOut string `json:"prevOut"`
+	Index   int    `json:"index"`
+}
+
+// OutputView of a transaction output with hex hashes
+type OutputView struct {
+	Address string `json:"address"`
+	Value   int    `json:"value"`
+}
+
+// TransactionView of a transaction for the explorer
+// LockHeight and LockTime the transaction has to wait for, 0 if not locked
+type TransactionView struct {
+	Name       string       `json:"name"`
+	Coinbase   bool         `json:"coinbase"`
+	Inputs     []InputView  `json:"inputs"`
+	Outputs    []OutputView `json:"outputs"`
+	Fee        int          `json:"fee"`
+	Data       string       `json:"data"`
+	Size       int          `json:"size"`
+	LockHeight int          `json:"lockHeight"`
+	LockTime   int64        `json:"lockTime"`
+}
+
+// BlockView of a block for the explorer
+// Height is -1 for orphan blocks
+// Confirmations counts the block and the canonical blocks on top of it,
+// it is 0 if the block is not in the canonical chain
+type BlockView struct {
+	Hash          string            `json:"hash"`
+	PrevHash      string            `json:"prevHash"`
+	MerkleRoot    string            `json:"merkleRoot"`
+	Target        string            `json:"target"`
+	Timestamp     int64             `json:"timestamp"`
+	Nonce         uint64            `json:"nonce"`
+	Height        int               `json:"height"`
+	Confirmations int               `json:"confirmations"`
+	Canonical     bool              `json:"canonical"`
+	Size          int               `json:"size"`
+	Transactions  []TransactionView `json:"transactions"`
+}
+
+// TransactionInfo of a transaction in the canonical chain or the pool
+// Block and Height of the block containing it, empty and -1 if pending
+// Locked if it is pending and not mature for the next block
+type TransactionInfo struct {
+	Transaction   TransactionView `json:"transaction"`
+	Block         string          `json:"block"`
+	Height        int             `json:"height"`
+	Confirmations int             `json:"confirmations"`
+	Pending       bool            `json:"pending"`
+	Locked        bool            `json:"locked"`
+}
+
+// AddressEntry of a canonical transaction touching an address
+// Received coins of the outputs paid to the address
+// Sent coins of the outputs of the address spent by the transaction
+type AddressEntry struct {
+	Tx        string `json:"tx"`
+	Block     string `json:"block"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Received  int    `json:"received"`
+	Sent      int    `json:"sent"`
+}
+
+// addressIndex of the canonical history of every address, the
+// entries of a block are added when it joins the canonical chain
+type addressIndex struct {
+	entries map[utils.HashValue][]AddressEntry
+	// touched addresses by height, to roll them back
+	touched [][]utils.HashValue
+	mux     sync.Mutex
+}
+
+func newAddressIndex() *addressIndex {
+	return &addressIndex{
+		entries: make(map[utils.HashValue][]AddressEntry),
+		touched: [][]utils.HashValue{},
+	}
+}
+
+// add the entries of the next canonical block
+func (index *addressIndex) add(entries map[utils.HashValue][]AddressEntry) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	touched := []utils.HashValue{}
+	for address, addressEntries := range entries {
+		index.entries[address] = append(index.entries[address], addressEntries...)
+		touched = append(touched, address)
+	}
+	index.touched = append(index.touched, touched)
+}
+
+// rollback drops the entries of the blocks from height on
+func (index *addressIndex) rollback(height int) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	for blockHeight := height; blockHeight < len(index.touched); blockHeight++ {
+		for _, address := range index.touched[blockHeight] {
+			entries := index.entries[address]
+			for len(entries) > 0 && entries[len(entries)-1].Height >= height {
+				entries = entries[:len(entries)-1]
+			}
+			if len(entries) == 0 {
+				delete(index.entries, address)
+			} else {
+				index.entries[address] = entries
+			}
+		}
+	}
+	if height < len(index.touched) {
+		index.touched = index.touched[:height]
+	}
+}
+
+// get a copy of the entries of the address
+func (index *addressIndex) get(address utils.HashValue) []AddressEntry {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	return append([]AddressEntry{}, index.entries[address]...)
+}
+
+// getAddressEntries of the block joining the canonical chain at height,
+// the outputs it spends have to be unspent yet to know their owners
+func (bc *BlockChain) getAddressEntries(block *Block, height int) map[utils.HashValue][]AddressEntry {
+	entries := make(map[utils.HashValue][]AddressEntry)
+	created := make(map[string]Output)
+	for index := range block.Body.Transactions {
+		tx := &block.Body.Transactions[index]
+		touched := []utils.HashValue{}
+		values := make(map[utils.HashValue]*AddressEntry)
+		entry := func(address utils.HashValue) *AddressEntry {
+			if _, ok := values[address]; !ok {
+				values[address] = &AddressEntry{Tx: tx.String(), Block: block.String(), Height: height, Timestamp: block.Header.Timestamp}
+				touched = append(touched, address)
+			}
+			return values[address]
+		}
+		if !tx.IsCoinbase() {
+			for _, input := range tx.Inputs {
+				key := outputKey(input.PrevOut, input.Index)
+				if out, ok := created[key]; ok {
+					entry(out.PubKeyHash).Sent += out.Value
+				} else if out, ok := bc.utxoSet.Get(input.PrevOut, input.Index); ok {
+					entry(out.PubKeyHash).Sent += out.Value
+				}
+			}
+		}
+		for outIndex, output := range tx.Outputs {
+			entry(output.PubKeyHash).Received += output.Value
+			created[outputKey(tx.Name, outIndex)] = output
+		}
+		for _, address := range touched {
+			entries[address] = append(entries[address], *values[address])
+		}
+	}
+	return entries
+}
+
+// NewTransactionView func
+func NewTransactionView(tx *TransactionMulti) TransactionView {
+	view := TransactionView{
+		Name:       tx.String(),
+		Coinbase:   tx.IsCoinbase(),
+		Inputs:     []InputView{},
+		Outputs:    []OutputView{},
+		Fee:        tx.Fee,
+		Data:       hex.EncodeToString(tx.Data),
+		Size:       tx.GetSize(),
+		LockHeight: tx.GetLockHeight(),
+		LockTime:   tx.GetLockTimestamp(),
+	}
+	for _, input := range tx.Inputs {
+		view.Inputs = append(view.Inputs, InputView{PrevOut: input.PrevOut.String(), Index: input.Index})
+	}
+	for _, output := range tx.Outputs {
+		view.Outputs = append(view.Outputs, OutputView{Address: output.PubKeyHash.String(), Value: output.Value})
+	}
+	return view
+}
+
+// newBlockView of a block at height of a chain with size blocks
+func newBlockView(block *Block, height, size int, canonical bool) *BlockView {
+	view := &BlockView{
+		Hash:         block.String(),
+		PrevHash:     block.PrintPrev(),
+		MerkleRoot:   block.Header.MerkleRoot.String(),
+		Target:       block.Header.Target.String(),
+		Timestamp:    block.Header.Timestamp,
+		Nonce:        block.Header.Nonce,
+		Height:       height,
+		Canonical:    canonical,
+		Size:         block.GetSize(),
+		Transactions: []TransactionView{},
+	}
+	if canonical {
+		view.Confirmations = size - height
+	}
+	for index := range block.Body.Transactions {
+		view.Transactions = append(view.Transactions, NewTransactionView(&block.Body.Transactions[index]))
+	}
+	return view
+}
+
+// GetBlocks returns up to limit canonical blocks starting at height from
+func (bc *BlockChain) GetBlocks(from, limit int) []*BlockView {
+	chain := bc.getCanonicalChain()
+	if limit <= 0 || limit > MAX_EXPLORER_BLOCKS {
+		limit = MAX_EXPLORER_BLOCKS
+	}
+	if from < 0 {
+		from = 0
+	}
+	views := []*BlockView{}
+	for height := from; height < chain.size() && len(views) < limit; height++ {
+		views = append(views, newBlockView(chain.Blocks[height], height, chain.size(), true))
+	}
+	return views
+}
+
+// GetBlockAtHeight returns the canonical block at height
+func (bc *BlockChain) GetBlockAtHeight(height int) (*BlockView, bool) {
+	chain := bc.getCanonicalChain()
+	if height < 0 || height >= chain.size() {
+		return nil, false
+	}
+	return newBlockView(chain.Blocks[height], height, chain.size(), true), true
+}
+
+// GetBlockView of any block known by the node
+func (bc *BlockChain) GetBlockView(hash string) (*BlockView, bool) {
+	chain := bc.getCanonicalChain()
+	if node, found := bc.index.Get(hash); found {
+		canonical := node.Height < chain.size() && chain.Blocks[node.Height].String() == hash
+		return newBlockView(node.Block, node.Height, chain.size(), canonical), true
+	}
+	if block, found := bc.index.GetBlock(hash); found {
+		return newBlockView(block, -1, chain.size(), false), true
+	}
+	return nil, false
+}
+
+// GetTransactionInfo of a transaction in the canonical chain or the pool
+func (bc *BlockChain) GetTransactionInfo(hash string) (*TransactionInfo, bool) {
+	bc.Lock()
+	chain := bc.canonicalChain
+	height, found := bc.txHeights[hash]
+	bc.Unlock()
+	if found {
+		block := chain.Blocks[height]
+		for index := range block.Body.Transactions {
+			tx := &block.Body.Transactions[index]
+			if tx.String() == hash {
+				return &TransactionInfo{
+					Transaction:   NewTransactionView(tx),
+					Block:         block.String(),
+					Height:        height,
+					Confirmations: chain.size() - height,
+				}, true
+			}
+		}
+	}
+	if tx, found := bc.mempool.Get(hash); found {
+		height, medianTime := bc.getNextBlockLock()
+		return &TransactionInfo{
+			Transaction: NewTransactionView(tx),
+			Height:      -1,
+			Pending:     true,
+			Locked:      !tx.IsMature(height, medianTime),
+		}, true
+	}
+	return nil, false
+}
+
+// GetAddressHistory returns the canonical transactions
+// that pay to the address or spend its outputs, oldest first
+func (bc *BlockChain) GetAddressHistory(address utils.HashValue) []AddressEntry {
+	return bc.addresses.get(address)
+}
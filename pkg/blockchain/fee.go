@@ -0,0 +1,85 @@
+package blockchain
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// FEE_ESTIMATE_BLOCKS recent canonical blocks sampled by the fee estimator
+	FEE_ESTIMATE_BLOCKS = 20
+	// MAX_FEE_TARGET blocks a transaction can be estimated to wait
+	MAX_FEE_TARGET = 25
+	// DEFAULT_FEE_TARGET blocks used when the fee is not given
+	DEFAULT_FEE_TARGET = 1
+	// DEFAULT_TX_SIZE bytes of a one input two outputs
+	// transaction, used until transactions are confirmed
+	DEFAULT_TX_SIZE = 700
+	// MIN_FEE coins of an estimate
+	MIN_FEE = 1
+)
+
+// FeeEstimate for a transaction to be mined within Blocks blocks
+// ConfirmedRate fee rate paid in the recent canonical blocks
+// PoolRate fee rate needed to get ahead of the pool in Blocks blocks
+// Fee coins paid by a transaction of Size bytes at FeeRate
+// Samples confirmed transactions the estimate is based on
+type FeeEstimate struct {
+	Blocks        int     `json:"blocks"`
+	FeeRate       float64 `json:"feeRate"`
+	ConfirmedRate float64 `json:"confirmedRate"`
+	PoolRate      float64 `json:"poolRate"`
+	Size          int     `json:"size"`
+	Fee           int     `json:"fee"`
+	Samples       int     `json:"samples"`
+}
+
+// EstimateFee compares the fee rates confirmed in the recent canonical
+// blocks with the pool, the longer a transaction can wait the lower
+// the confirmed rate it has to match
+func (bc *BlockChain) EstimateFee(blocks int) FeeEstimate {
+	if blocks < 1 {
+		blocks = 1
+	}
+	if blocks > MAX_FEE_TARGET {
+		blocks = MAX_FEE_TARGET
+	}
+	estimate := FeeEstimate{Blocks: blocks, Size: DEFAULT_TX_SIZE}
+
+	chain := bc.getCanonicalChain()
+	rates, sizes := []float64{}, []int{}
+	for height := chain.size() - 1; height > 0 && height >= chain.size()-FEE_ESTIMATE_BLOCKS; height-- {
+		for index := range chain.Blocks[height].Body.Transactions {
+			tx := &chain.Blocks[height].Body.Transactions[index]
+			if tx.IsCoinbase() {
+				continue
+			}
+			size := tx.GetSize()
+			rates = append(rates, float64(tx.Fee)/float64(size))
+			sizes = append(sizes, size)
+		}
+	}
+	if estimate.Samples = len(rates); estimate.Samples > 0 {
+		sort.Float64s(rates)
+		sort.Ints(sizes)
+		// the median for the next block, lower percentiles for later ones
+		estimate.ConfirmedRate = rates[len(rates)/(blocks+1)]
+		estimate.Size = sizes[len(sizes)/2]
+	}
+
+	// rate of the first pool transaction left out of the next blocks
+	capacity := blocks * MaxBlockSize
+	for _, entry := range bc.mempool.GetEntries() {
+		if capacity -= entry.Size + txFraming; capacity < 0 {
+			estimate.PoolRate = float64(entry.Tx.Fee) / float64(entry.Size)
+			break
+		}
+	}
+
+	estimate.FeeRate = math.Max(estimate.ConfirmedRate, estimate.PoolRate)
+	estimate.Fee = int(math.Ceil(estimate.FeeRate * float64(estimate.Size)))
+	if estimate.Fee < MIN_FEE {
+		estimate.Fee = MIN_FEE
+	}
+	return estimate
+}
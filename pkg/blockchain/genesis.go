@@ -0,0 +1,145 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// DEFAULT_NETWORK preset used when no network is given
+const DEFAULT_NETWORK = "main"
+
+// NetworkParams struct
+// Definition of the genesis block and the rules of a network,
+// nodes only accept blocks of peers with the same genesis
+// Target hex of the starting difficulty target
+// BlockReward coins paid to the miner of a block
+// HalvingInterval blocks after which the reward halves, 0 to never halve
+// Allocations coins owned by a pubkey hash in the genesis
+// AllowUnsealed if blocks can be sealed by the engines without
+// proof of work, only meant for test networks
+type NetworkParams struct {
+	NetworkID       string       `json:"networkId"`
+	Timestamp       int64        `json:"timestamp"`
+	Target          string       `json:"target"`
+	BlockReward     int          `json:"blockReward"`
+	HalvingInterval int          `json:"halvingInterval"`
+	Allocations     []Allocation `json:"allocations"`
+	AllowUnsealed   bool         `json:"allowUnsealed"`
+}
+
+// Allocation struct
+// Address hex of the pubkey hash that owns the coins
+type Allocation struct {
+	Address string `json:"address"`
+	Value   int    `json:"value"`
+}
+
+// NetworkPresets built in networks
+var NetworkPresets = map[string]NetworkParams{
+	"main": NetworkParams{
+		NetworkID:       "gambercoin-main",
+		Timestamp:       1540000000,
+		Target:          "00000fffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		BlockReward:     50,
+		HalvingInterval: 210000,
+		Allocations:     []Allocation{},
+	},
+	"staging": NetworkParams{
+		NetworkID:       "gambercoin-staging",
+		Timestamp:       1540000000,
+		Target:          PowLimit.String(),
+		BlockReward:     50,
+		HalvingInterval: 1000,
+		Allocations:     []Allocation{},
+	},
+	"test": NetworkParams{
+		NetworkID:       "gambercoin-test",
+		Timestamp:       1540000000,
+		Target:          PowLimit.String(),
+		BlockReward:     50,
+		HalvingInterval: 100,
+		Allocations:     []Allocation{},
+		AllowUnsealed:   true,
+	},
+}
+
+// LoadNetworkParams returns the preset with the given
+// name or reads the params from the JSON file in that path
+func LoadNetworkParams(network string) (*NetworkParams, error) {
+	if network == "" {
+		network = DEFAULT_NETWORK
+	}
+	params := &NetworkParams{}
+	if preset, ok := NetworkPresets[network]; ok {
+		*params = preset
+	} else {
+		content, err := ioutil.ReadFile(network)
+		if err != nil {
+			return nil, fmt.Errorf("network %v is not a preset or a readable file: %v", network, err)
+		}
+		if err := json.Unmarshal(content, params); err != nil {
+			return nil, fmt.Errorf("network file %v not valid: %v", network, err)
+		}
+	}
+	if _, err := params.GenesisBlock(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// GetTarget parses the starting difficulty target
+func (params *NetworkParams) GetTarget() (utils.HashValue, error) {
+	target, err := utils.GetHash(params.Target)
+	if err != nil {
+		return target, fmt.Errorf("target not valid: %v", err)
+	}
+	if target == (utils.HashValue{}) || !MeetsTarget(target, PowLimit) {
+		return target, fmt.Errorf("target %v out of range", params.Target)
+	}
+	return target, nil
+}
+
+// GenesisBlock builds the first block of the network,
+// its coinbase pays the allocations and stores the network id
+func (params *NetworkParams) GenesisBlock() (*Block, error) {
+	if params.NetworkID == "" {
+		return nil, errors.New("network id is empty")
+	}
+	if params.BlockReward < 0 || params.BlockReward > MAX_MONEY {
+		return nil, fmt.Errorf("block reward %v out of range", params.BlockReward)
+	}
+	if params.HalvingInterval < 0 {
+		return nil, fmt.Errorf("halving interval %v is negative", params.HalvingInterval)
+	}
+	target, err := params.GetTarget()
+	if err != nil {
+		return nil, err
+	}
+	outputs := []Output{}
+	for _, allocation := range params.Allocations {
+		address, err := utils.GetHash(allocation.Address)
+		if err != nil {
+			return nil, fmt.Errorf("allocation address not valid: %v", err)
+		}
+		if allocation.Value <= 0 || allocation.Value > MAX_MONEY {
+			return nil, fmt.Errorf("allocation to %v out of range", allocation.Address)
+		}
+		outputs = append(outputs, Output{PubKeyHash: address, Value: allocation.Value})
+	}
+	coinbase := TransactionMulti{
+		Inputs:  []Input{Input{PrevOut: utils.HashValue{}, Index: 0}},
+		Outputs: outputs,
+		Data:    utils.Bytes(params.NetworkID),
+	}
+	coinbase.Name = coinbase.Hash()
+
+	genesis := NewBlock([32]byte{})
+	genesis.Header.Timestamp = params.Timestamp
+	genesis.Header.Target = target
+	genesis.AppendTransaction(coinbase)
+	return genesis, nil
+}
@@ -0,0 +1,146 @@
+package blockchain
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// MAX_REORG_HISTORY reorganizations kept by the node
+	MAX_REORG_HISTORY = 50
+)
+
+// PendingTransaction in the pool
+// FeeRate coins per byte of the encoded transaction
+// Age seconds since the transaction entered the pool
+// Locked if the transaction is not mature for the next block
+type PendingTransaction struct {
+	Transaction TransactionView `json:"transaction"`
+	Fee         int             `json:"fee"`
+	Size        int             `json:"size"`
+	FeeRate     float64         `json:"feeRate"`
+	Added       int64           `json:"added"`
+	Age         int64           `json:"age"`
+	Locked      bool            `json:"locked"`
+}
+
+// ForkBlock known by the node outside of the canonical chain
+// Height is -1 for orphans, whose parent is unknown
+// Invalid if the block or one of its ancestors failed validation
+type ForkBlock struct {
+	Hash      string `json:"hash"`
+	PrevHash  string `json:"prevHash"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Orphan    bool   `json:"orphan"`
+	Invalid   bool   `json:"invalid"`
+}
+
+// Reorg of the canonical chain
+// Depth canonical blocks rolled back to the fork
+// Returned transactions of the rolled back blocks left in the pool
+type Reorg struct {
+	Time       int64    `json:"time"`
+	Depth      int      `json:"depth"`
+	ForkHeight int      `json:"forkHeight"`
+	OldTip     string   `json:"oldTip"`
+	NewTip     string   `json:"newTip"`
+	NewHeight  int      `json:"newHeight"`
+	Returned   []string `json:"returned"`
+}
+
+// GetPendingTransactions from the highest to the lowest fee rate
+func (bc *BlockChain) GetPendingTransactions() []PendingTransaction {
+	now := time.Now().Unix()
+	height, medianTime := bc.getNextBlockLock()
+	pending := []PendingTransaction{}
+	for _, entry := range bc.mempool.GetEntries() {
+		rate := 0.0
+		if entry.Size > 0 {
+			rate = float64(entry.Tx.Fee) / float64(entry.Size)
+		}
+		pending = append(pending, PendingTransaction{
+			Transaction: NewTransactionView(entry.Tx),
+			Fee:         entry.Tx.Fee,
+			Size:        entry.Size,
+			FeeRate:     rate,
+			Added:       entry.Added,
+			Age:         now - entry.Added,
+			Locked:      !entry.Tx.IsMature(height, medianTime),
+		})
+	}
+	return pending
+}
+
+// GetForkBlocks returns the side chain and orphan
+// blocks known by the node, the highest first
+func (bc *BlockChain) GetForkBlocks() []ForkBlock {
+	chain := bc.getCanonicalChain()
+	blocks := []ForkBlock{}
+	for _, node := range bc.index.GetNodes() {
+		if node.Height < chain.size() && chain.Blocks[node.Height].String() == node.Hash {
+			continue
+		}
+		blocks = append(blocks, ForkBlock{
+			Hash:      node.Hash,
+			PrevHash:  node.Block.PrintPrev(),
+			Height:    node.Height,
+			Timestamp: node.Block.Header.Timestamp,
+			Invalid:   node.Invalid,
+		})
+	}
+	for _, block := range bc.index.GetOrphans() {
+		blocks = append(blocks, ForkBlock{
+			Hash:      block.String(),
+			PrevHash:  block.PrintPrev(),
+			Height:    -1,
+			Timestamp: block.Header.Timestamp,
+			Orphan:    true,
+		})
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].Height != blocks[j].Height {
+			return blocks[i].Height > blocks[j].Height
+		}
+		return blocks[i].Hash < blocks[j].Hash
+	})
+	return blocks
+}
+
+// GetReorgs returns the last reorganizations, the most recent first
+func (bc *BlockChain) GetReorgs() []Reorg {
+	bc.Lock()
+	defer bc.Unlock()
+	reorgs := []Reorg{}
+	for index := len(bc.reorgs) - 1; index >= 0; index-- {
+		reorgs = append(reorgs, bc.reorgs[index])
+	}
+	return reorgs
+}
+
+// addReorg records the move of the canonical chain from oldTip,
+// only the returned transactions still in the pool are kept
+func (bc *BlockChain) addReorg(oldTip *BlockNode, forkHeight int, returned []string) {
+	inPool := []string{}
+	for _, hash := range returned {
+		if bc.mempool.Has(hash) {
+			inPool = append(inPool, hash)
+		}
+	}
+	newTip, newHeight := bc.GetTip()
+	reorg := Reorg{
+		Time:       time.Now().Unix(),
+		Depth:      oldTip.Height - forkHeight,
+		ForkHeight: forkHeight,
+		OldTip:     oldTip.Hash,
+		NewTip:     newTip,
+		NewHeight:  newHeight - 1,
+		Returned:   inPool,
+	}
+	bc.Lock()
+	bc.reorgs = append(bc.reorgs, reorg)
+	if len(bc.reorgs) > MAX_REORG_HISTORY {
+		bc.reorgs = bc.reorgs[len(bc.reorgs)-MAX_REORG_HISTORY:]
+	}
+	bc.Unlock()
+}
@@ -0,0 +1,36 @@
+package blockchain
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// MarshalPubKey encodes a public key so that
+// it can travel inside a transaction input
+func MarshalPubKey(pubKey *rsa.PublicKey) utils.Bytes {
+	return x509.MarshalPKCS1PublicKey(pubKey)
+}
+
+// ParsePubKey decodes a public key encoded with MarshalPubKey
+func ParsePubKey(bytes utils.Bytes) (*rsa.PublicKey, error) {
+	return x509.ParsePKCS1PublicKey(bytes)
+}
+
+// MarshalPrivateKey encodes a private key to store or import it
+func MarshalPrivateKey(key *rsa.PrivateKey) utils.Bytes {
+	return x509.MarshalPKCS1PrivateKey(key)
+}
+
+// ParsePrivateKey decodes a private key encoded with MarshalPrivateKey
+func ParsePrivateKey(bytes utils.Bytes) (*rsa.PrivateKey, error) {
+	return x509.ParsePKCS1PrivateKey(bytes)
+}
+
+// GetPubKeyHash returns the address that owns the
+// outputs spendable with the given public key
+func GetPubKeyHash(pubKey *rsa.PublicKey) utils.HashValue {
+	return sha256.Sum256(MarshalPubKey(pubKey))
+}
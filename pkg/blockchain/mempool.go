@@ -0,0 +1,265 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// MaxBlockSize bytes of an encoded block,
+	// blocks have to fit in a single UDP packet
+	MaxBlockSize = 60000
+	// txFraming bytes added to a transaction
+	// when it is encoded inside a block
+	txFraming = 4
+)
+
+// MempoolConfig struct
+// MaxCount maximum number of transactions
+// MaxBytes maximum size of all transactions
+// Expiry seconds a transaction can wait in the pool
+type MempoolConfig struct {
+	MaxCount int
+	MaxBytes int
+	Expiry   int64
+}
+
+// DefaultMempoolConfig func
+func DefaultMempoolConfig() MempoolConfig {
+	return MempoolConfig{
+		MaxCount: 5000,
+		MaxBytes: 5 * 1024 * 1024,
+		Expiry:   24 * 60 * 60,
+	}
+}
+
+// MempoolEntry struct
+// Size bytes of the encoded transaction
+// Added unix time when the transaction entered the pool
+// Matured unix time since the transaction can be mined, the expiry
+// counts from it so timelocked transactions wait until they mature
+type MempoolEntry struct {
+	Tx      *TransactionMulti
+	Size    int
+	Added   int64
+	Matured int64
+}
+
+// hasHigherFeeRate compares fee per byte without
+// rounding, ties are broken by age and then name
+func (entry *MempoolEntry) hasHigherFeeRate(other *MempoolEntry) bool {
+	rate, otherRate := entry.Tx.Fee*other.Size, other.Tx.Fee*entry.Size
+	if rate != otherRate {
+		return rate > otherRate
+	}
+	if entry.Added != other.Added {
+		return entry.Added < other.Added
+	}
+	return entry.Tx.String() < other.Tx.String()
+}
+
+// Mempool struct
+// Pool of verified transactions waiting to be mined,
+// it keeps which outputs they spend to detect conflicts
+type Mempool struct {
+	config  MempoolConfig
+	entries map[string]*MempoolEntry
+	spends  map[string]string // output key -> spending tx
+	bytes   int
+	mux     sync.Mutex
+}
+
+// NewMempool func
+func NewMempool(config MempoolConfig) *Mempool {
+	return &Mempool{
+		config:  config,
+		entries: make(map[string]*MempoolEntry),
+		spends:  make(map[string]string),
+	}
+}
+
+// Add the transaction to the pool, when it is full the entries with
+// the lowest fee rate are evicted to make room and returned
+func (pool *Mempool) Add(tx *TransactionMulti) (evicted []*TransactionMulti, err error) {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	if _, ok := pool.entries[tx.String()]; ok {
+		return nil, nil
+	}
+	now := time.Now().Unix()
+	entry := &MempoolEntry{Tx: tx, Size: tx.GetSize(), Added: now, Matured: now}
+	if entry.Size+txFraming > MaxBlockSize || entry.Size > pool.config.MaxBytes {
+		return nil, fmt.Errorf("transaction of %v bytes is too big", entry.Size)
+	}
+	victims := []*MempoolEntry{}
+	count, bytes := len(pool.entries)+1, pool.bytes+entry.Size
+	for _, lowest := range pool.sortedEntries(false) {
+		if count <= pool.config.MaxCount && bytes <= pool.config.MaxBytes {
+			break
+		}
+		if !entry.hasHigherFeeRate(lowest) {
+			return nil, errors.New("pool is full and the fee rate is too low")
+		}
+		victims = append(victims, lowest)
+		count--
+		bytes -= lowest.Size
+	}
+	if count > pool.config.MaxCount || bytes > pool.config.MaxBytes {
+		return nil, errors.New("pool is full")
+	}
+	for _, victim := range victims {
+		pool.remove(victim.Tx.String())
+		evicted = append(evicted, victim.Tx)
+	}
+	pool.entries[tx.String()] = entry
+	pool.bytes += entry.Size
+	for _, in := range tx.Inputs {
+		pool.spends[outputKey(in.PrevOut, in.Index)] = tx.String()
+	}
+	return evicted, nil
+}
+
+// CheckLock rejects transactions locked further ahead than the expiry,
+// height locks are counted at the target block time from the next height
+func (pool *Mempool) CheckLock(tx *TransactionMulti, height int, now int64) error {
+	if blocks := tx.GetLockHeight() - height; int64(blocks)*TargetBlockTime > pool.config.Expiry {
+		return fmt.Errorf("transaction locked %v blocks ahead, over the pool expiry", blocks)
+	}
+	if seconds := tx.GetLockTimestamp() - now; seconds > pool.config.Expiry {
+		return fmt.Errorf("transaction locked %v seconds ahead, over the pool expiry", seconds)
+	}
+	return nil
+}
+
+// Remove the transaction from the pool
+func (pool *Mempool) Remove(hash string) (*TransactionMulti, bool) {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	return pool.remove(hash)
+}
+
+// Expire removes and returns the transactions that have been mature
+// in the pool longer than the expiry, a transaction is mature if it
+// can be included in a block at height with the median time past
+func (pool *Mempool) Expire(now int64, height int, medianTime int64) []*TransactionMulti {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	expired := []*TransactionMulti{}
+	for hash, entry := range pool.entries {
+		if !entry.Tx.IsMature(height, medianTime) {
+			entry.Matured = now
+		} else if now-entry.Matured > pool.config.Expiry {
+			pool.remove(hash)
+			expired = append(expired, entry.Tx)
+		}
+	}
+	return expired
+}
+
+// Get transaction from the pool
+func (pool *Mempool) Get(hash string) (*TransactionMulti, bool) {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	entry, ok := pool.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	return entry.Tx, true
+}
+
+// Has check if the transaction is in the pool
+func (pool *Mempool) Has(hash string) bool {
+	_, ok := pool.Get(hash)
+	return ok
+}
+
+// GetSpender returns the transaction in the pool spending the output
+func (pool *Mempool) GetSpender(prevOut utils.HashValue, index int) (string, bool) {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	spender, found := pool.spends[outputKey(prevOut, index)]
+	return spender, found
+}
+
+// GetEntries ordered from the highest to the lowest fee rate
+func (pool *Mempool) GetEntries() []MempoolEntry {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	entries := []MempoolEntry{}
+	for _, entry := range pool.sortedEntries(true) {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+// GetTransactions ordered from the highest to the lowest fee rate
+func (pool *Mempool) GetTransactions() []*TransactionMulti {
+	transactions := []*TransactionMulti{}
+	for _, entry := range pool.GetEntries() {
+		transactions = append(transactions, entry.Tx)
+	}
+	return transactions
+}
+
+// SelectForBlock picks the transactions with the highest fee rate
+// that fit in maxBytes once encoded in a block at height whose
+// parent has the median time past, immature transactions wait
+func (pool *Mempool) SelectForBlock(maxBytes, height int, medianTime int64) []*TransactionMulti {
+	selected := []*TransactionMulti{}
+	for _, entry := range pool.GetEntries() {
+		if entry.Size+txFraming <= maxBytes && entry.Tx.IsMature(height, medianTime) {
+			selected = append(selected, entry.Tx)
+			maxBytes -= entry.Size + txFraming
+		}
+	}
+	return selected
+}
+
+// Count of transactions in the pool
+func (pool *Mempool) Count() int {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	return len(pool.entries)
+}
+
+// Bytes of all the transactions in the pool
+func (pool *Mempool) Bytes() int {
+	pool.mux.Lock()
+	defer pool.mux.Unlock()
+	return pool.bytes
+}
+
+func (pool *Mempool) remove(hash string) (*TransactionMulti, bool) {
+	entry, ok := pool.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	for _, in := range entry.Tx.Inputs {
+		key := outputKey(in.PrevOut, in.Index)
+		if pool.spends[key] == hash {
+			delete(pool.spends, key)
+		}
+	}
+	delete(pool.entries, hash)
+	pool.bytes -= entry.Size
+	return entry.Tx, true
+}
+
+func (pool *Mempool) sortedEntries(descending bool) []*MempoolEntry {
+	entries := []*MempoolEntry{}
+	for _, entry := range pool.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if descending {
+			return entries[i].hasHigherFeeRate(entries[j])
+		}
+		return entries[j].hasHigherFeeRate(entries[i])
+	})
+	return entries
+}
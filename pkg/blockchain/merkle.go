@@ -0,0 +1,82 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// MerkleProof struct
+// Index position of the transaction in the block
+// Siblings hashes needed to rebuild the root, from the leaves up
+type MerkleProof struct {
+	Index    int
+	Siblings []utils.HashValue
+}
+
+// ComputeMerkleRoot of a list of hashes, when a level
+// has an odd number of nodes the last one is paired with itself,
+// so a list repeating its last hashes can have the same root
+func ComputeMerkleRoot(hashes []utils.HashValue) utils.HashValue {
+	if len(hashes) == 0 {
+		return utils.HashValue{}
+	}
+	level := append([]utils.HashValue{}, hashes...)
+	for len(level) > 1 {
+		level = nextMerkleLevel(level)
+	}
+	return level[0]
+}
+
+// BuildMerkleProof for the hash in index
+func BuildMerkleProof(hashes []utils.HashValue, index int) *MerkleProof {
+	proof := &MerkleProof{Index: index, Siblings: []utils.HashValue{}}
+	level := append([]utils.HashValue{}, hashes...)
+	for position := index; len(level) > 1; position /= 2 {
+		sibling := position ^ 1
+		if sibling >= len(level) {
+			sibling = position
+		}
+		proof.Siblings = append(proof.Siblings, level[sibling])
+		level = nextMerkleLevel(level)
+	}
+	return proof
+}
+
+// VerifyMerkleProof checks that the hash is included under the root
+func VerifyMerkleProof(hash, root utils.HashValue, proof *MerkleProof) bool {
+	if proof == nil || proof.Index < 0 {
+		return false
+	}
+	position := proof.Index
+	for _, sibling := range proof.Siblings {
+		if position%2 == 0 {
+			hash = hashMerklePair(hash, sibling)
+		} else {
+			hash = hashMerklePair(sibling, hash)
+		}
+		position /= 2
+	}
+	return position == 0 && hash == root
+}
+
+func nextMerkleLevel(level []utils.HashValue) []utils.HashValue {
+	next := []utils.HashValue{}
+	for index := 0; index < len(level); index += 2 {
+		right := level[index]
+		if index+1 < len(level) {
+			right = level[index+1]
+		}
+		next = append(next, hashMerklePair(level[index], right))
+	}
+	return next
+}
+
+func hashMerklePair(left, right utils.HashValue) utils.HashValue {
+	h := sha256.New()
+	h.Write(left[:])
+	h.Write(right[:])
+	var out utils.HashValue
+	copy(out[:], h.Sum(nil))
+	return out
+}
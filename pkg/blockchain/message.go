@@ -1,8 +1,12 @@
 package blockchain
 
+import "github.com/ageapps/gambercoin/pkg/utils"
+
 // TxMessage struct
+// Genesis hash of the network of the sender
 type TxMessage struct {
-	Tx       Transaction
+	Tx       TransactionMulti
+	Genesis  utils.HashValue
 	HopLimit uint32
 }
 
@@ -13,10 +17,12 @@ type TxMessage struct {
 // }
 
 // ChainMessage struct
+// Result if not nil receives whether the transaction entered the pool
 type ChainMessage struct {
-	Tx     *Transaction
+	Tx     *TransactionMulti
 	Block  *Block
 	Origin string
+	Result chan error
 }
 
 // IsTx check
@@ -26,7 +32,7 @@ func (msg *ChainMessage) IsTx() bool {
 
 // IsTx check
 func (msg *ChainMessage) IsBlock() bool {
-	return msg.Block == nil && msg.Block != nil
+	return msg.Tx == nil && msg.Block != nil
 }
 
 // // BlockBundle struct
@@ -36,17 +42,48 @@ func (msg *ChainMessage) IsBlock() bool {
 // }
 
 // BlockMessage struct
+// Genesis hash of the network of the sender
 type BlockMessage struct {
 	Block    Block
+	Genesis  utils.HashValue
 	HopLimit uint32
 }
 
 // NewTxMessage func
-func NewTxMessage(tx Transaction, hops uint32) *TxMessage {
-	return &TxMessage{tx, hops}
+func NewTxMessage(tx TransactionMulti, genesis utils.HashValue, hops uint32) *TxMessage {
+	return &TxMessage{tx, genesis, hops}
 }
 
 // NewBlockMessage func
-func NewBlockMessage(block Block, hops uint32) *BlockMessage {
-	return &BlockMessage{block, hops}
+func NewBlockMessage(block Block, genesis utils.HashValue, hops uint32) *BlockMessage {
+	return &BlockMessage{block, genesis, hops}
+}
+
+// ChainTip struct
+// sent to ask a peer for its tip and as reply,
+// Time is the clock of the sender in seconds
+// Work cumulative work of the tip as big endian bytes
+type ChainTip struct {
+	Tip     string
+	Height  int
+	Work    utils.Bytes
+	Time    int64
+	Genesis utils.HashValue
+}
+
+// HashesRequest asks for the names of the canonical
+// blocks after the first locator hash the peer knows
+type HashesRequest struct {
+	Locator []string
+}
+
+// HashesReply struct
+type HashesReply struct {
+	Hashes []string
+}
+
+// BlocksRequest asks for blocks by name,
+// they are sent back as BlockMessages
+type BlocksRequest struct {
+	Hashes []string
 }
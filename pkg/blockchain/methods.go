@@ -5,15 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ageapps/gambercoin/pkg/logger"
 	"github.com/ageapps/gambercoin/pkg/utils"
 )
 
-func (bc *BlockChain) getBlockPool() map[string]*Block {
-	bc.Lock()
-	defer bc.Unlock()
-	return bc.blockPool
-}
-
 func (bc *BlockChain) setPrevHash(newPrev [32]byte) {
 	bc.Lock()
 	bc.prevHash = newPrev
@@ -35,15 +30,34 @@ func (bc *BlockChain) isMining() bool {
 	return bc.minig
 }
 
-func (bc *BlockChain) resetTransactionPool() {
+// startMining begins a new mining round, a miner
+// of a previous round stops when it checks the round
+func (bc *BlockChain) startMining() uint64 {
 	bc.Lock()
-	bc.tansactionPool = make(map[string]*Transaction)
+	defer bc.Unlock()
+	bc.miningRound++
+	bc.minig = true
+	return bc.miningRound
+}
+
+// stopMining only if the round is still the current one
+func (bc *BlockChain) stopMining(round uint64) {
+	bc.Lock()
+	if bc.miningRound == round {
+		bc.minig = false
+	}
 	bc.Unlock()
 }
 
-func (bc *BlockChain) setCurrentNonce(nonce [32]byte) {
+func (bc *BlockChain) isMiningRound(round uint64) bool {
+	bc.Lock()
+	defer bc.Unlock()
+	return bc.minig && bc.miningRound == round
+}
+
+func (bc *BlockChain) setCurrentNonce(nonce uint64) {
 	bc.Lock()
-	bc.currentBlock.Nonce = nonce
+	bc.currentBlock.Header.Nonce = nonce
 	bc.Unlock()
 }
 func (bc *BlockChain) setCurrentBlock(block Block) {
@@ -53,8 +67,22 @@ func (bc *BlockChain) setCurrentBlock(block Block) {
 }
 func (bc *BlockChain) restoreCanonicalChain(newChain Chain) {
 	bc.Lock()
+	// the new chain is a prefix of the current one
+	for height := newChain.size(); height < bc.canonicalChain.size(); height++ {
+		bc.unindexTransactions(bc.canonicalChain.Blocks[height], height)
+	}
 	bc.canonicalChain = newChain
 	bc.Unlock()
+	bc.addresses.rollback(newChain.size())
+	if newChain.size() > 0 && !bc.restoring {
+		bc.saveTip(newChain.Blocks[newChain.size()-1])
+	}
+}
+
+func (bc *BlockChain) saveTip(block *Block) {
+	if err := bc.store.SaveTip(block); err != nil {
+		logger.Logw("Error storing tip %v: %v", block.String(), err)
+	}
 }
 
 func (bc *BlockChain) getCurrentBlock() Block {
@@ -62,6 +90,16 @@ func (bc *BlockChain) getCurrentBlock() Block {
 	defer bc.Unlock()
 	return bc.currentBlock
 }
+
+// getTipName returns the name of the last canonical block
+func (bc *BlockChain) getTipName() string {
+	bc.Lock()
+	defer bc.Unlock()
+	return bc.canonicalChain.Blocks[bc.canonicalChain.size()-1].String()
+}
+
+// getCanonicalChain returns a copy that can be read without the lock,
+// the canonical chain only appends past its end or is replaced
 func (bc *BlockChain) getCanonicalChain() Chain {
 	bc.Lock()
 	defer bc.Unlock()
@@ -73,44 +111,28 @@ func (bc *BlockChain) getPrevHash() utils.HashValue {
 	return bc.prevHash
 }
 
-func (bc *BlockChain) getTransactionPool() map[string]*Transaction {
-	bc.Lock()
-	defer bc.Unlock()
-	return bc.tansactionPool
-}
-
-func (bc *BlockChain) getSideChains() []*Chain {
+func (bc *BlockChain) isTransactionInCanonicalChain(newTransaction *TransactionMulti) bool {
 	bc.Lock()
 	defer bc.Unlock()
-	return bc.sideChains
+	_, found := bc.txHeights[newTransaction.String()]
+	return found
 }
 
-func (bc *BlockChain) resetSideChains() {
-	bc.Lock()
-	bc.sideChains = []*Chain{}
-	bc.Unlock()
-}
-
-func (bc *BlockChain) addSideChain(chain *Chain) {
-	bc.Lock()
-	bc.sideChains = append(bc.sideChains, chain)
-	bc.Unlock()
-}
-
-func (bc *BlockChain) deleteSideChain(index int) {
-	bc.sideChains = append(bc.sideChains[:index], bc.sideChains[index+1:]...)
+// indexTransactions of the canonical block at height, the lock is held
+func (bc *BlockChain) indexTransactions(block *Block, height int) {
+	for index := range block.Body.Transactions {
+		bc.txHeights[block.Body.Transactions[index].String()] = height
+	}
 }
 
-func (bc *BlockChain) isTransactionInCanonicalChain(newTransaction *Transaction) bool {
-	for _, block := range bc.getCanonicalChain().Blocks {
-		for _, transaction := range block.Transactions {
-			tx := transaction // because of ponter issues
-			if tx.String() == newTransaction.String() {
-				return true
-			}
+// unindexTransactions of the canonical block at height, the lock is held
+func (bc *BlockChain) unindexTransactions(block *Block, height int) {
+	for index := range block.Body.Transactions {
+		name := block.Body.Transactions[index].String()
+		if bc.txHeights[name] == height {
+			delete(bc.txHeights, name)
 		}
 	}
-	return false
 }
 
 func (bc *BlockChain) logChain() {
@@ -120,11 +142,11 @@ func (bc *BlockChain) logChain() {
 		block := cChain.Blocks[index]
 		str += block.String()
 		str += ":"
-		str += hex.EncodeToString(block.PrevHash[:])
+		str += hex.EncodeToString(block.Header.PrevHash[:])
 		str += ":"
-		for index := 0; index < len(block.Transactions); index++ {
-			str += block.Transactions[index].String()
-			if index < len(block.Transactions)-1 {
+		for index := 0; index < len(block.Body.Transactions); index++ {
+			str += block.Body.Transactions[index].String()
+			if index < len(block.Body.Transactions)-1 {
 				str += ","
 			}
 		}
@@ -160,7 +182,7 @@ func (bc *BlockChain) sendBlockToProcess(bl *Block) {
 		}
 	}
 }
-func (bc *BlockChain) sendTransaction(tx *Transaction) {
+func (bc *BlockChain) sendTransaction(tx *TransactionMulti) {
 	if bc.isActive() {
 		bc.sendChannel <- ChainMessage{
 			Tx:     tx,
@@ -168,16 +190,6 @@ func (bc *BlockChain) sendTransaction(tx *Transaction) {
 		}
 	}
 }
-func (bc *BlockChain) getBlockTime() uint64 {
-	bc.Lock()
-	defer bc.Unlock()
-	return bc.blockTime
-}
-func (bc *BlockChain) setBlockTime(t uint64) {
-	bc.Lock()
-	bc.blockTime = t
-	bc.Unlock()
-}
 
 func getTimestamp() int64 {
 	return time.Now().UnixNano()
@@ -0,0 +1,148 @@
+package blockchain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// MiningStats of the node
+// Enabled if the node builds blocks, Mining if it is sealing one now
+// RewardAddress where the block rewards are paid
+// Hashes done since the node started
+// Hashrate in hashes per second of the current or last mining round
+// BlocksFound sealed by the node, they can still lose against other blocks
+type MiningStats struct {
+	Enabled       bool    `json:"enabled"`
+	Mining        bool    `json:"mining"`
+	RewardAddress string  `json:"rewardAddress"`
+	Engine        string  `json:"engine"`
+	Threads       int     `json:"threads"`
+	Hashes        uint64  `json:"hashes"`
+	Hashrate      float64 `json:"hashrate"`
+	BlocksFound   int     `json:"blocksFound"`
+}
+
+// miningSample keeps the hashes and time
+// of the current or last mining round
+type miningSample struct {
+	start       time.Time
+	end         time.Time
+	startHashes uint64
+	endHashes   uint64
+	blocksFound int
+}
+
+func (sample *miningSample) getHashrate(hashes uint64, mining bool) float64 {
+	end := sample.end
+	if mining {
+		end = time.Now()
+		sample.endHashes = hashes
+	}
+	seconds := end.Sub(sample.start).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(sample.endHashes-sample.startHashes) / seconds
+}
+
+// getEngineHashes of the engine, zero if it does not hash
+func (bc *BlockChain) getEngineHashes() uint64 {
+	if engine, ok := bc.engine.(HashingEngine); ok {
+		return engine.GetHashes()
+	}
+	return 0
+}
+
+func (bc *BlockChain) startMiningStats() {
+	hashes := bc.getEngineHashes()
+	bc.Lock()
+	bc.stats.start = time.Now()
+	bc.stats.startHashes = hashes
+	bc.stats.endHashes = hashes
+	bc.Unlock()
+}
+
+func (bc *BlockChain) finishMiningStats(sealed bool) {
+	hashes := bc.getEngineHashes()
+	bc.Lock()
+	bc.stats.end = time.Now()
+	bc.stats.endHashes = hashes
+	if sealed {
+		bc.stats.blocksFound++
+	}
+	bc.Unlock()
+}
+
+// GetMiningStats of the node miner
+func (bc *BlockChain) GetMiningStats() MiningStats {
+	hashes := bc.getEngineHashes()
+	threads := 1
+	if engine, ok := bc.engine.(HashingEngine); ok {
+		threads = engine.GetThreads()
+	}
+	bc.Lock()
+	defer bc.Unlock()
+	return MiningStats{
+		Enabled:       bc.mineEnabled,
+		Mining:        bc.minig,
+		RewardAddress: bc.minerHash.String(),
+		Engine:        bc.engine.Name(),
+		Threads:       threads,
+		Hashes:        hashes,
+		Hashrate:      bc.stats.getHashrate(hashes, bc.minig),
+		BlocksFound:   bc.stats.blocksFound,
+	}
+}
+
+func (bc *BlockChain) isMiningEnabled() bool {
+	bc.Lock()
+	defer bc.Unlock()
+	return bc.mineEnabled
+}
+
+// SetMiningEnabled turns the miner on or off, a node
+// with mining off only validates and relays blocks
+func (bc *BlockChain) SetMiningEnabled(enabled bool) {
+	bc.Lock()
+	bc.mineEnabled = enabled
+	bc.Unlock()
+	if !enabled {
+		bc.setMining(false)
+		return
+	}
+	// the block is built in the chain routine
+	select {
+	case bc.mineChannel <- true:
+	default:
+	}
+}
+
+// GetMinerHash returns the address the block rewards are paid to
+func (bc *BlockChain) GetMinerHash() utils.HashValue {
+	bc.Lock()
+	defer bc.Unlock()
+	return bc.minerHash
+}
+
+// SetMinerHash changes the address the block rewards
+// are paid to, it is used from the next block built
+func (bc *BlockChain) SetMinerHash(minerHash utils.HashValue) {
+	bc.Lock()
+	bc.minerHash = minerHash
+	bc.Unlock()
+}
+
+// SetMiningThreads of the engine, used from the next block built
+func (bc *BlockChain) SetMiningThreads(threads int) error {
+	engine, ok := bc.engine.(HashingEngine)
+	if !ok {
+		return fmt.Errorf("consensus engine %v does not use threads", bc.engine.Name())
+	}
+	if threads < 1 {
+		return fmt.Errorf("%v mining threads not valid", threads)
+	}
+	engine.SetThreads(threads)
+	return nil
+}
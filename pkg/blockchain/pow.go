@@ -0,0 +1,163 @@
+package blockchain
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// sealCheckPeriod hashes a worker does between abort checks
+	sealCheckPeriod = 1024
+)
+
+// HashingEngine is implemented by the engines that
+// seal blocks hashing with several worker goroutines
+type HashingEngine interface {
+	ConsensusEngine
+	// GetHashes done since the engine was created
+	GetHashes() uint64
+	GetThreads() int
+	SetThreads(threads int)
+}
+
+// ProofOfWork engine, a block is sealed when its hash
+// meets the target that is retargeted every RetargetInterval blocks
+type ProofOfWork struct {
+	hashes  uint64
+	threads int32
+}
+
+// NewProofOfWork engine that hashes with the number of threads
+func NewProofOfWork(threads int) *ProofOfWork {
+	engine := &ProofOfWork{}
+	engine.SetThreads(threads)
+	return engine
+}
+
+// Name func
+func (engine *ProofOfWork) Name() string {
+	return CONSENSUS_POW
+}
+
+// GetTarget func
+func (engine *ProofOfWork) GetTarget(chain *Chain) utils.HashValue {
+	return getTargetForChain(chain)
+}
+
+// VerifySeal func
+func (engine *ProofOfWork) VerifySeal(block *Block) error {
+	if !checkProofOfWork(block) {
+		return errors.New("proof of work not valid")
+	}
+	return nil
+}
+
+// VerifyBlock func
+func (engine *ProofOfWork) VerifyBlock(chain *Chain, block *Block) error {
+	return verifyTarget(engine, chain, block)
+}
+
+// SealEmptyBlocks func
+func (engine *ProofOfWork) SealEmptyBlocks() bool {
+	return false
+}
+
+// GetHashes func
+func (engine *ProofOfWork) GetHashes() uint64 {
+	return atomic.LoadUint64(&engine.hashes)
+}
+
+// GetThreads func
+func (engine *ProofOfWork) GetThreads() int {
+	if threads := atomic.LoadInt32(&engine.threads); threads > 0 {
+		return int(threads)
+	}
+	return 1
+}
+
+// SetThreads used from the next seal, at least one is used
+func (engine *ProofOfWork) SetThreads(threads int) {
+	if threads < 1 {
+		threads = 1
+	}
+	atomic.StoreInt32(&engine.threads, int32(threads))
+}
+
+// Seal splits the nonce space between the workers, worker i tries
+// the nonces i, i+threads, i+2*threads... and when its range is
+// exhausted moves to the next extra nonce of the coinbase.
+// All the workers stop as soon as one finds the seal or abort is true
+func (engine *ProofOfWork) Seal(chain *Chain, block *Block, abort func() bool) bool {
+	threads := engine.GetThreads()
+	var found int32
+	var sealed Block
+	var wg sync.WaitGroup
+	for worker := 0; worker < threads; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			candidate := *block
+			candidate.Body.Transactions = append([]TransactionMulti{}, block.Body.Transactions...)
+			if engine.search(&candidate, uint64(worker), uint64(threads), &found, abort) {
+				sealed = candidate
+			}
+		}(worker)
+	}
+	wg.Wait()
+	if atomic.LoadInt32(&found) == 0 {
+		return false
+	}
+	*block = sealed
+	return true
+}
+
+// search the nonces of the worker, returns true if
+// this worker is the one that sealed the block
+func (engine *ProofOfWork) search(block *Block, first, step uint64, found *int32, abort func() bool) bool {
+	extraNonce := uint64(0)
+	nonce := first
+	hashes := uint64(0)
+	defer func() {
+		atomic.AddUint64(&engine.hashes, hashes)
+	}()
+	for {
+		if hashes%sealCheckPeriod == 0 {
+			if atomic.LoadInt32(found) != 0 || abort() {
+				return false
+			}
+		}
+		block.Header.Nonce = nonce
+		hashes++
+		if MeetsTarget(block.Hash(), block.Header.Target) {
+			return atomic.CompareAndSwapInt32(found, 0, 1)
+		}
+		next := nonce + step
+		if next < nonce {
+			// nonce range exhausted
+			if len(block.Body.Transactions) == 0 || !block.Body.Transactions[0].IsCoinbase() {
+				return false
+			}
+			extraNonce++
+			setExtraNonce(block, extraNonce)
+			next = first
+		}
+		nonce = next
+	}
+}
+
+// setExtraNonce of the coinbase of the block
+func setExtraNonce(block *Block, extraNonce uint64) {
+	block.Body.Transactions[0].SetExtraNonce(extraNonce)
+	block.Header.MerkleRoot = block.ComputeMerkleRoot()
+}
+
+// checkProofOfWork of the block against its own target
+func checkProofOfWork(bl *Block) bool {
+	if !MeetsTarget(bl.Header.Target, PowLimit) {
+		return false
+	}
+	return MeetsTarget(bl.Hash(), bl.Header.Target)
+}
@@ -0,0 +1,167 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/dedis/protobuf"
+)
+
+// SpentOutput referenced by an input of an unsigned transaction
+type SpentOutput struct {
+	PrevOut string `json:"prevOut"`
+	Index   int    `json:"index"`
+	Address string `json:"address"`
+	Value   int    `json:"value"`
+}
+
+// UnsignedTransaction built by a node to be signed offline
+// Tx hex encoded transaction without signatures
+// Spent outputs of the inputs, the signer has no access to the
+// chain and needs them to know which key signs every input
+type UnsignedTransaction struct {
+	Tx          string          `json:"tx"`
+	Spent       []SpentOutput   `json:"spent"`
+	Transaction TransactionView `json:"transaction"`
+}
+
+// EncodeTransaction returns the hex encoding of the transaction
+func EncodeTransaction(tx *TransactionMulti) (string, error) {
+	packet, err := protobuf.Encode(tx)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(packet), nil
+}
+
+// DecodeTransaction from its hex encoding, the name has to match its content
+func DecodeTransaction(raw string) (*TransactionMulti, error) {
+	packet, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	tx := &TransactionMulti{}
+	if err := protobuf.Decode(packet, tx); err != nil {
+		return nil, err
+	}
+	if tx.Name != tx.Hash() {
+		return nil, errors.New("transaction name does not match its content")
+	}
+	return tx, nil
+}
+
+// GetUnspentOutputs spent by the transaction owned by the address,
+// the unsigned transaction is the source of the offline signer
+func (unsigned *UnsignedTransaction) GetUnspentOutputs(hash utils.HashValue) []UnspentOutput {
+	unspent := []UnspentOutput{}
+	for _, spent := range unsigned.Spent {
+		if spent.Address != hash.String() {
+			continue
+		}
+		prevOut, err := utils.GetHash(spent.PrevOut)
+		if err != nil {
+			continue
+		}
+		unspent = append(unspent, UnspentOutput{
+			PrevOut: prevOut,
+			Index:   spent.Index,
+			Output:  Output{PubKeyHash: hash, Value: spent.Value},
+		})
+	}
+	return unspent
+}
+
+// CheckSpent outputs against the inputs of the transaction, they have
+// to be in the same order and hold exactly the outputs plus the fee
+func (unsigned *UnsignedTransaction) CheckSpent(tx *TransactionMulti) error {
+	if len(tx.Inputs) != len(unsigned.Spent) {
+		return errors.New("spent outputs do not match the inputs")
+	}
+	spentValue := 0
+	for index, spent := range unsigned.Spent {
+		in := tx.Inputs[index]
+		if spent.PrevOut != in.PrevOut.String() || spent.Index != in.Index {
+			return fmt.Errorf("spent output %v does not match input %v", index, outputKey(in.PrevOut, in.Index))
+		}
+		var err error
+		if spentValue, err = addMoney(spentValue, spent.Value); err != nil {
+			return fmt.Errorf("spent output %v: %v", index, err)
+		}
+	}
+	outputValue, err := tx.CheckOutputValue()
+	if err != nil {
+		return err
+	}
+	if tx.Fee < 0 || tx.Fee > MAX_MONEY || spentValue != outputValue+tx.Fee {
+		return fmt.Errorf("spent %v coins, outputs %v and fee %v", spentValue, outputValue, tx.Fee)
+	}
+	return nil
+}
+
+// CreateRawTransaction builds an unsigned transaction spending
+// the chosen confirmed outputs, the coins of the inputs not
+// assigned to the outputs are the fee, lockTime is 0 if not locked
+func (bc *BlockChain) CreateRawTransaction(inputs []Input, outputs []Output, lockTime int64) (*UnsignedTransaction, error) {
+	if len(inputs) == 0 || len(outputs) == 0 {
+		return nil, errors.New("transaction needs inputs and outputs")
+	}
+	if lockTime < 0 {
+		return nil, fmt.Errorf("lock time %v is negative", lockTime)
+	}
+	spent := []SpentOutput{}
+	inputValue := 0
+	for index, in := range inputs {
+		out, found := bc.utxoSet.Get(in.PrevOut, in.Index)
+		if !found {
+			return nil, fmt.Errorf("input %v spends unknown output %v", index, outputKey(in.PrevOut, in.Index))
+		}
+		if spender, found := bc.getPoolSpender(in.PrevOut, in.Index); found {
+			return nil, fmt.Errorf("input %v spends %v, already spent by %v in pool", index, outputKey(in.PrevOut, in.Index), spender)
+		}
+		spent = append(spent, SpentOutput{
+			PrevOut: in.PrevOut.String(),
+			Index:   in.Index,
+			Address: out.PubKeyHash.String(),
+			Value:   out.Value,
+		})
+		var err error
+		if inputValue, err = addMoney(inputValue, out.Value); err != nil {
+			return nil, fmt.Errorf("input %v: %v", index, err)
+		}
+	}
+	tx := NewTransactionMulti(inputs, outputs, 0)
+	tx.LockTime = lockTime
+	outputValue, err := tx.CheckOutputValue()
+	if err != nil {
+		return nil, err
+	}
+	tx.Fee = inputValue - outputValue
+	if tx.Fee < 0 {
+		return nil, fmt.Errorf("outputs spend %v coins but inputs hold %v", outputValue, inputValue)
+	}
+	tx.Name = tx.Hash()
+	raw, err := EncodeTransaction(&tx)
+	if err != nil {
+		return nil, err
+	}
+	return &UnsignedTransaction{Tx: raw, Spent: spent, Transaction: NewTransactionView(&tx)}, nil
+}
+
+// VerifyTransaction checks a signed transaction against the
+// confirmed outputs and the pool before it is relayed
+func (bc *BlockChain) VerifyTransaction(tx *TransactionMulti) error {
+	if bc.isTransactionKnown(tx) {
+		return fmt.Errorf("transaction %v already known", tx.String())
+	}
+	return bc.verifyPoolTransaction(tx)
+}
+
+// SubmitTransaction to the pool of the running chain,
+// it waits until the transaction is added or rejected
+func (bc *BlockChain) SubmitTransaction(tx *TransactionMulti, origin string) error {
+	result := make(chan error, 1)
+	bc.ReceiveChannel <- ChainMessage{Tx: tx, Origin: origin, Result: result}
+	return <-result
+}
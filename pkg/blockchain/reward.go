@@ -0,0 +1,41 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GetBlockReward for the block at height,
+// it halves every HalvingInterval blocks
+func (params *NetworkParams) GetBlockReward(height int) int {
+	if params.HalvingInterval <= 0 {
+		return params.BlockReward
+	}
+	halvings := height / params.HalvingInterval
+	if halvings >= 63 {
+		return 0
+	}
+	return params.BlockReward >> uint(halvings)
+}
+
+// verifyCoinbase checks that the coinbase of the block at
+// height pays positive outputs worth at most maxValue
+func verifyCoinbase(coinbase *TransactionMulti, height, maxValue int) error {
+	if coinbase.Name != coinbase.Hash() {
+		return errors.New("coinbase name does not match its content")
+	}
+	if coinbase.Inputs[0].Index != height {
+		return fmt.Errorf("coinbase height %v expected %v", coinbase.Inputs[0].Index, height)
+	}
+	if coinbase.Fee != 0 {
+		return errors.New("coinbase can not pay fees")
+	}
+	value, err := coinbase.CheckOutputValue()
+	if err != nil {
+		return fmt.Errorf("coinbase %v", err)
+	}
+	if value > maxValue {
+		return fmt.Errorf("coinbase claims %v coins but only %v are available", value, maxValue)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package blockchain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// TX_UNKNOWN transaction never seen by the node
+	TX_UNKNOWN = "unknown"
+	// TX_PENDING transaction waiting in the pool
+	TX_PENDING = "pending"
+	// TX_CONFIRMED transaction in a canonical block
+	TX_CONFIRMED = "confirmed"
+	// TX_REORGED transaction of a block rolled back by a reorganization
+	TX_REORGED = "reorged"
+	// TX_REJECTED transaction not valid or dropped from the pool
+	TX_REJECTED = "rejected"
+	// MAX_TRACKED_TRANSACTIONS statuses kept by the node
+	MAX_TRACKED_TRANSACTIONS = 10000
+	// MAX_NOTIFICATIONS kept for every watched address
+	MAX_NOTIFICATIONS = 100
+)
+
+// TransactionStatus of a transaction
+// Block and Height of the canonical block containing it, empty and -1 otherwise
+// Reason why it was rejected or reorged
+type TransactionStatus struct {
+	Tx            string `json:"tx"`
+	Status        string `json:"status"`
+	Block         string `json:"block"`
+	Height        int    `json:"height"`
+	Confirmations int    `json:"confirmations"`
+	Reason        string `json:"reason"`
+	Updated       int64  `json:"updated"`
+}
+
+// Notification of a change of state of a payment of a watched address
+// Incoming if the address receives Value coins, outgoing if it spends them
+type Notification struct {
+	ID       uint64 `json:"id"`
+	Address  string `json:"address"`
+	Tx       string `json:"tx"`
+	Status   string `json:"status"`
+	Incoming bool   `json:"incoming"`
+	Value    int    `json:"value"`
+	Height   int    `json:"height"`
+	Reason   string `json:"reason"`
+	Time     int64  `json:"time"`
+}
+
+// payment of a transaction to or from an address
+type payment struct {
+	address  utils.HashValue
+	value    int
+	incoming bool
+}
+
+// txTracker keeps the last status of the transactions seen by the node
+// and the notifications of the addresses accepted by watch, the payments
+// are kept since the spent outputs are gone once the transaction is mined
+type txTracker struct {
+	statuses      map[string]*TransactionStatus
+	payments      map[string][]payment
+	order         []string
+	notifications map[utils.HashValue][]Notification
+	lastID        uint64
+	watch         func(utils.HashValue) bool
+	mux           sync.Mutex
+}
+
+func newTxTracker() *txTracker {
+	return &txTracker{
+		statuses:      make(map[string]*TransactionStatus),
+		payments:      make(map[string][]payment),
+		order:         []string{},
+		notifications: make(map[utils.HashValue][]Notification),
+	}
+}
+
+// update the status of the transaction and notify its watched payments,
+// confirmations and reorgs are only kept for transactions already
+// tracked or touching a watched address
+func (tracker *txTracker) update(status TransactionStatus, payments []payment, always bool) {
+	tracker.mux.Lock()
+	defer tracker.mux.Unlock()
+	if cached := tracker.payments[status.Tx]; len(cached) > len(payments) {
+		payments = cached
+	}
+	watched := []payment{}
+	for _, pay := range payments {
+		if tracker.watch != nil && tracker.watch(pay.address) {
+			watched = append(watched, pay)
+		}
+	}
+	previous, tracked := tracker.statuses[status.Tx]
+	if !tracked && !always && len(watched) == 0 {
+		return
+	}
+	if tracked && previous.Status == status.Status && previous.Block == status.Block {
+		return
+	}
+	if !tracked {
+		tracker.order = append(tracker.order, status.Tx)
+		if len(tracker.order) > MAX_TRACKED_TRANSACTIONS {
+			delete(tracker.statuses, tracker.order[0])
+			delete(tracker.payments, tracker.order[0])
+			tracker.order = tracker.order[1:]
+		}
+	}
+	tracker.statuses[status.Tx] = &status
+	tracker.payments[status.Tx] = payments
+	for _, pay := range watched {
+		tracker.lastID++
+		notifications := append(tracker.notifications[pay.address], Notification{
+			ID:       tracker.lastID,
+			Address:  pay.address.String(),
+			Tx:       status.Tx,
+			Status:   status.Status,
+			Incoming: pay.incoming,
+			Value:    pay.value,
+			Height:   status.Height,
+			Reason:   status.Reason,
+			Time:     status.Updated,
+		})
+		if len(notifications) > MAX_NOTIFICATIONS {
+			notifications = notifications[len(notifications)-MAX_NOTIFICATIONS:]
+		}
+		tracker.notifications[pay.address] = notifications
+	}
+}
+
+func (tracker *txTracker) get(hash string) (TransactionStatus, bool) {
+	tracker.mux.Lock()
+	defer tracker.mux.Unlock()
+	status, found := tracker.statuses[hash]
+	if !found {
+		return TransactionStatus{}, false
+	}
+	return *status, true
+}
+
+// WatchAddresses sets which addresses get notifications
+func (bc *BlockChain) WatchAddresses(watch func(utils.HashValue) bool) {
+	bc.tracker.mux.Lock()
+	bc.tracker.watch = watch
+	bc.tracker.mux.Unlock()
+}
+
+// GetNotifications of the address with an id greater than since, oldest first
+func (bc *BlockChain) GetNotifications(address utils.HashValue, since uint64) []Notification {
+	bc.tracker.mux.Lock()
+	defer bc.tracker.mux.Unlock()
+	notifications := []Notification{}
+	for _, notification := range bc.tracker.notifications[address] {
+		if notification.ID > since {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications
+}
+
+// GetTransactionStatus returns the tracked status of the transaction,
+// the canonical chain and the pool have the last word on it
+func (bc *BlockChain) GetTransactionStatus(hash string) TransactionStatus {
+	status, found := bc.tracker.get(hash)
+	if !found {
+		status = TransactionStatus{Tx: hash, Status: TX_UNKNOWN, Height: -1}
+	}
+	info, found := bc.GetTransactionInfo(hash)
+	switch {
+	case found && info.Pending:
+		// a reorged transaction back in the pool keeps the reason
+		if status.Status != TX_REORGED {
+			status.Reason = ""
+		}
+		status.Status = TX_PENDING
+		status.Block, status.Height, status.Confirmations = "", -1, 0
+	case found:
+		status.Status = TX_CONFIRMED
+		status.Block, status.Height, status.Confirmations = info.Block, info.Height, info.Confirmations
+		status.Reason = ""
+	case status.Status == TX_PENDING || status.Status == TX_CONFIRMED:
+		// left the pool or the chain without being tracked
+		status.Status = TX_UNKNOWN
+	}
+	return status
+}
+
+// trackTransaction records a new status of the transaction
+func (bc *BlockChain) trackTransaction(tx *TransactionMulti, status, reason string, block *Block, height int) {
+	txStatus := TransactionStatus{
+		Tx:      tx.String(),
+		Status:  status,
+		Height:  -1,
+		Reason:  reason,
+		Updated: time.Now().Unix(),
+	}
+	if block != nil {
+		txStatus.Block = block.String()
+		txStatus.Height = height
+	}
+	always := status == TX_PENDING || status == TX_REJECTED
+	bc.tracker.update(txStatus, bc.getPayments(tx), always)
+}
+
+// trackBlock records the status of every transaction of the block
+func (bc *BlockChain) trackBlock(block *Block, height int, status, reason string) {
+	for index := range block.Body.Transactions {
+		bc.trackTransaction(&block.Body.Transactions[index], status, reason, block, height)
+	}
+}
+
+// rejectTransaction records why the transaction is not valid or left the pool
+func (bc *BlockChain) rejectTransaction(tx *TransactionMulti, reason string) {
+	bc.trackTransaction(tx, TX_REJECTED, reason, nil, -1)
+}
+
+// getPayments of the transaction, the owners of the spent
+// outputs are only known while the outputs are unspent
+func (bc *BlockChain) getPayments(tx *TransactionMulti) []payment {
+	payments := []payment{}
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Inputs {
+			if out, found := bc.utxoSet.Get(in.PrevOut, in.Index); found {
+				payments = append(payments, payment{address: out.PubKeyHash, value: out.Value})
+			}
+		}
+	}
+	for _, out := range tx.Outputs {
+		payments = append(payments, payment{address: out.PubKeyHash, value: out.Value, incoming: true})
+	}
+	return payments
+}
@@ -0,0 +1,146 @@
+package blockchain
+
+import (
+	"sync"
+
+	"github.com/ageapps/gambercoin/pkg/logger"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// BlockStore persists the blocks that joined the canonical chain,
+// its tip and the transaction pool
+type BlockStore interface {
+	SaveBlock(block *Block) error
+	SaveTip(block *Block) error
+	SaveTransaction(tx *TransactionMulti) error
+	DeleteTransaction(hash utils.HashValue) error
+	Load() (*StoredChain, error)
+	Close() error
+}
+
+// StoredChain struct
+// Blocks in the order they were saved
+// Tip name of the last block of the canonical chain, it is
+// saved again on restore only if the rebuilt chain ends elsewhere
+type StoredChain struct {
+	Blocks       []*Block
+	Tip          string
+	Transactions []*TransactionMulti
+}
+
+// MemoryStore keeps everything in memory, used for tests
+// and for nodes that don't need to survive restarts
+type MemoryStore struct {
+	stored StoredChain
+	blocks map[string]bool
+	mux    sync.Mutex
+}
+
+// NewMemoryStore func
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		stored: StoredChain{Blocks: []*Block{}, Transactions: []*TransactionMulti{}},
+		blocks: make(map[string]bool),
+	}
+}
+
+// SaveBlock func
+func (store *MemoryStore) SaveBlock(block *Block) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	if !store.blocks[block.String()] {
+		store.blocks[block.String()] = true
+		store.stored.Blocks = append(store.stored.Blocks, block)
+	}
+	return nil
+}
+
+// SaveTip func
+func (store *MemoryStore) SaveTip(block *Block) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	store.stored.Tip = block.String()
+	return nil
+}
+
+// SaveTransaction func
+func (store *MemoryStore) SaveTransaction(tx *TransactionMulti) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	for _, stored := range store.stored.Transactions {
+		if stored.Name == tx.Name {
+			return nil
+		}
+	}
+	store.stored.Transactions = append(store.stored.Transactions, tx)
+	return nil
+}
+
+// DeleteTransaction func
+func (store *MemoryStore) DeleteTransaction(hash utils.HashValue) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	txs := []*TransactionMulti{}
+	for _, tx := range store.stored.Transactions {
+		if tx.Name != hash {
+			txs = append(txs, tx)
+		}
+	}
+	store.stored.Transactions = txs
+	return nil
+}
+
+// Load func
+func (store *MemoryStore) Load() (*StoredChain, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	stored := StoredChain{
+		Blocks:       append([]*Block{}, store.stored.Blocks...),
+		Tip:          store.stored.Tip,
+		Transactions: append([]*TransactionMulti{}, store.stored.Transactions...),
+	}
+	return &stored, nil
+}
+
+// Close func
+func (store *MemoryStore) Close() error {
+	return nil
+}
+
+// restoreFromStore adds the stored blocks to the block index
+// and rebuilds the canonical chain up to the tip with most work,
+// every block is verified again before it is added. The replayed
+// blocks are not written again, only the tip if it changed
+func (bc *BlockChain) restoreFromStore() {
+	stored, err := bc.store.Load()
+	if err != nil {
+		logger.Logw("Error loading block store: %v", err)
+		stored = &StoredChain{}
+	}
+	bc.restoring = true
+	bc.addToBlockChain(bc.genesis)
+	for _, block := range stored.Blocks {
+		// blocks without parent belong to another network
+		if block.Header.PrevHash != [32]byte{} {
+			bc.index.Add(block)
+		}
+	}
+	bc.updateCanonicalChain()
+	bc.restoring = false
+	if chain := bc.getCanonicalChain(); chain.Blocks[chain.size()-1].String() != stored.Tip {
+		bc.saveTip(chain.Blocks[chain.size()-1])
+	}
+	for _, tx := range stored.Transactions {
+		if err := bc.verifyPoolTransaction(tx); err != nil {
+			logger.Logw("Stored transaction %v not valid: %v", tx.String(), err)
+			bc.store.DeleteTransaction(tx.Name)
+			continue
+		}
+		if err := bc.addToTransactionPool(tx); err != nil {
+			logger.Logw("Stored transaction %v not added: %v", tx.String(), err)
+			bc.store.DeleteTransaction(tx.Name)
+		}
+	}
+	connected, orphans := bc.index.Size()
+	logger.Logi("Restored chain of %v blocks, %v known and %v orphans", bc.canonicalChain.size(), connected, orphans)
+}
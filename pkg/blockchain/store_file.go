@@ -0,0 +1,241 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/ageapps/gambercoin/pkg/logger"
+	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/dedis/protobuf"
+)
+
+const (
+	// STORE_FILE name of the log inside the data directory
+	STORE_FILE = "chain.log"
+
+	recordBlock    byte = 1
+	recordTip      byte = 2
+	recordTxAdd    byte = 3
+	recordTxDelete byte = 4
+	// maxRecordSize of a record payload, blocks and
+	// transactions are never bigger than a block
+	maxRecordSize = MaxBlockSize + txFraming
+)
+
+// tipRecord struct
+type tipRecord struct {
+	Tip string
+}
+
+// FileStore appends every change to a log file
+// in the data directory of the node, the state is
+// rebuilt replaying the log when it is loaded
+type FileStore struct {
+	file   *os.File
+	blocks map[string]bool
+	txs    map[string]bool
+	tip    string
+	mux    sync.Mutex
+}
+
+// NewFileStore opens or creates the store in dir
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path.Join(dir, STORE_FILE), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// never overwrite records that were not loaded yet
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileStore{
+		file:   file,
+		blocks: make(map[string]bool),
+		txs:    make(map[string]bool),
+	}, nil
+}
+
+// SaveBlock func
+func (store *FileStore) SaveBlock(block *Block) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	if store.blocks[block.String()] {
+		return nil
+	}
+	if err := store.append(recordBlock, block, true); err != nil {
+		return err
+	}
+	store.blocks[block.String()] = true
+	return nil
+}
+
+// SaveTip func
+func (store *FileStore) SaveTip(block *Block) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	if store.tip == block.String() {
+		return nil
+	}
+	if err := store.append(recordTip, &tipRecord{Tip: block.String()}, true); err != nil {
+		return err
+	}
+	store.tip = block.String()
+	return nil
+}
+
+// SaveTransaction func
+func (store *FileStore) SaveTransaction(tx *TransactionMulti) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	if store.txs[tx.String()] {
+		return nil
+	}
+	if err := store.append(recordTxAdd, tx, false); err != nil {
+		return err
+	}
+	store.txs[tx.String()] = true
+	return nil
+}
+
+// DeleteTransaction func
+func (store *FileStore) DeleteTransaction(hash utils.HashValue) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	if !store.txs[hash.String()] {
+		return nil
+	}
+	tx := &TransactionMulti{Name: hash}
+	if err := store.append(recordTxDelete, tx, false); err != nil {
+		return err
+	}
+	delete(store.txs, hash.String())
+	return nil
+}
+
+// Load replays the log, a record cut by a crash
+// ends the log and is overwritten by the next write
+func (store *FileStore) Load() (*StoredChain, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	if _, err := store.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	stored := &StoredChain{Blocks: []*Block{}, Transactions: []*TransactionMulti{}}
+	txs := make(map[string]*TransactionMulti)
+	txOrder := []string{}
+	reader := bufio.NewReader(store.file)
+	var offset int64
+
+	for {
+		recordType, payload, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Logw("Block store truncated at %v: %v", offset, err)
+			break
+		}
+		offset += int64(5 + len(payload))
+
+		switch recordType {
+		case recordBlock:
+			block := &Block{}
+			if err := protobuf.Decode(payload, block); err != nil {
+				return nil, err
+			}
+			stored.Blocks = append(stored.Blocks, block)
+			store.blocks[block.String()] = true
+		case recordTip:
+			tip := &tipRecord{}
+			if err := protobuf.Decode(payload, tip); err != nil {
+				return nil, err
+			}
+			stored.Tip = tip.Tip
+		case recordTxAdd:
+			tx := &TransactionMulti{}
+			if err := protobuf.Decode(payload, tx); err != nil {
+				return nil, err
+			}
+			if _, ok := txs[tx.String()]; !ok {
+				txOrder = append(txOrder, tx.String())
+			}
+			txs[tx.String()] = tx
+		case recordTxDelete:
+			tx := &TransactionMulti{}
+			if err := protobuf.Decode(payload, tx); err != nil {
+				return nil, err
+			}
+			delete(txs, tx.String())
+		default:
+			return nil, fmt.Errorf("unknown record %v in block store", recordType)
+		}
+	}
+	for _, hash := range txOrder {
+		if tx, ok := txs[hash]; ok && !store.txs[hash] {
+			stored.Transactions = append(stored.Transactions, tx)
+			store.txs[hash] = true
+		}
+	}
+	store.tip = stored.Tip
+	// drop incomplete records and keep appending after the last valid one
+	if err := store.file.Truncate(offset); err != nil {
+		return nil, err
+	}
+	if _, err := store.file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// Close func
+func (store *FileStore) Close() error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+	return store.file.Close()
+}
+
+func (store *FileStore) append(recordType byte, record interface{}, sync bool) error {
+	payload, err := protobuf.Encode(record)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 5)
+	header[0] = recordType
+	binary.LittleEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := store.file.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	if sync {
+		return store.file.Sync()
+	}
+	return nil
+}
+
+func readRecord(reader *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if n, err := io.ReadFull(reader, header); err != nil {
+		if n == 0 && err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		return 0, nil, errors.New("incomplete record header")
+	}
+	size := binary.LittleEndian.Uint32(header[1:])
+	if size > maxRecordSize {
+		return 0, nil, fmt.Errorf("record of %v bytes is too big", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, errors.New("incomplete record payload")
+	}
+	return header[0], payload, nil
+}
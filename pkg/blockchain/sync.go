@@ -0,0 +1,94 @@
+package blockchain
+
+import "math/big"
+
+// GetTip returns the name and height of the canonical tip
+func (bc *BlockChain) GetTip() (string, int) {
+	chain := bc.getCanonicalChain()
+	if chain.size() == 0 {
+		return "", 0
+	}
+	return chain.Blocks[chain.size()-1].String(), chain.size()
+}
+
+// GetTipWork returns the cumulative work of the canonical chain
+func (bc *BlockChain) GetTipWork() *big.Int {
+	node, ok := bc.index.Get(bc.getTipName())
+	if !ok {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(node.Work)
+}
+
+// GetLocator returns names of canonical blocks starting at the tip,
+// the step between them doubles after the first ten
+// and the first block of the chain is always included
+func (bc *BlockChain) GetLocator() []string {
+	chain := bc.getCanonicalChain()
+	locator := []string{}
+	step := 1
+	for index := chain.size() - 1; index > 0; index -= step {
+		locator = append(locator, chain.Blocks[index].String())
+		if len(locator) >= 10 {
+			step *= 2
+		}
+	}
+	if chain.size() > 0 {
+		locator = append(locator, chain.Blocks[0].String())
+	}
+	return locator
+}
+
+// GetHashesAfterLocator returns up to limit names of canonical blocks
+// after the first locator entry found, from the first block if none is
+func (bc *BlockChain) GetHashesAfterLocator(locator []string, limit int) []string {
+	chain := bc.getCanonicalChain()
+	heights := make(map[string]int)
+	for index, block := range chain.Blocks {
+		heights[block.String()] = index
+	}
+	start := 0
+	for _, hash := range locator {
+		if height, ok := heights[hash]; ok {
+			start = height + 1
+			break
+		}
+	}
+	hashes := []string{}
+	for index := start; index < chain.size() && len(hashes) < limit; index++ {
+		hashes = append(hashes, chain.Blocks[index].String())
+	}
+	return hashes
+}
+
+// GetBlock by name from the block index
+func (bc *BlockChain) GetBlock(hash string) (*Block, bool) {
+	return bc.index.GetBlock(hash)
+}
+
+// GetRequestedBlocks returns up to limit of the requested blocks
+// known by the node, unknown names are skipped
+func (bc *BlockChain) GetRequestedBlocks(hashes []string, limit int) []*Block {
+	blocks := []*Block{}
+	for _, hash := range hashes {
+		if len(blocks) >= limit {
+			break
+		}
+		if block, found := bc.GetBlock(hash); found {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// HasBlock check
+func (bc *BlockChain) HasBlock(hash string) bool {
+	_, found := bc.GetBlock(hash)
+	return found
+}
+
+// GetMissingParents returns the parents of the
+// orphan blocks that are not known by the node
+func (bc *BlockChain) GetMissingParents() []string {
+	return bc.index.GetMissingParents()
+}
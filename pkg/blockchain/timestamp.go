@@ -0,0 +1,120 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/logger"
+)
+
+const (
+	// MedianTimeBlocks number of previous blocks
+	// used to compute the median time past
+	MedianTimeBlocks = 11
+	// MaxFutureBlockTime seconds a block can be
+	// ahead of the network time
+	MaxFutureBlockTime = 2 * 60
+	// MaxTimeOffset seconds the network time is
+	// allowed to differ from the local clock
+	MaxTimeOffset = 5 * 60
+	// MinTimeSamples peers needed before their offsets
+	// are used, a single peer can not move the clock
+	MinTimeSamples = 5
+)
+
+// NetworkClock struct
+// Local clock adjusted with the median of
+// the offsets reported by the peers
+type NetworkClock struct {
+	offsets map[string]int64
+	offset  int64
+	mux     sync.Mutex
+}
+
+// NewNetworkClock func
+func NewNetworkClock() *NetworkClock {
+	return &NetworkClock{offsets: make(map[string]int64)}
+}
+
+// AddSample registers the time reported by a peer
+func (clock *NetworkClock) AddSample(peer string, peerTime int64) {
+	clock.mux.Lock()
+	defer clock.mux.Unlock()
+	clock.offsets[peer] = peerTime - time.Now().Unix()
+	if len(clock.offsets) < MinTimeSamples {
+		clock.offset = 0
+		return
+	}
+	offsets := []int64{}
+	for _, offset := range clock.offsets {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	median := offsets[len(offsets)/2]
+	if median > MaxTimeOffset || median < -MaxTimeOffset {
+		logger.Logw("Peers time differs %v seconds from local clock, ignoring it", median)
+		median = 0
+	}
+	clock.offset = median
+}
+
+// Offset in seconds of the network time
+func (clock *NetworkClock) Offset() int64 {
+	clock.mux.Lock()
+	defer clock.mux.Unlock()
+	return clock.offset
+}
+
+// Now returns the network time in seconds
+func (clock *NetworkClock) Now() int64 {
+	return time.Now().Unix() + clock.Offset()
+}
+
+// getMedianTimePast of the last blocks of the chain
+func getMedianTimePast(chain *Chain) int64 {
+	start := chain.size() - MedianTimeBlocks
+	if start < 0 {
+		start = 0
+	}
+	timestamps := []int64{}
+	for _, block := range chain.Blocks[start:] {
+		timestamps = append(timestamps, block.Header.Timestamp)
+	}
+	if len(timestamps) == 0 {
+		return 0
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	return timestamps[len(timestamps)/2]
+}
+
+// getNextTimestamp for a block that extends the chain,
+// it always is later than the median time past
+func getNextTimestamp(chain *Chain, now int64) int64 {
+	if median := getMedianTimePast(chain); now <= median {
+		return median + 1
+	}
+	return now
+}
+
+// checkFutureTimestamp rejects blocks too far ahead of the network time
+func checkFutureTimestamp(block *Block, now int64) error {
+	if block.Header.Timestamp > now+MaxFutureBlockTime {
+		return fmt.Errorf("timestamp %v too far in the future", block.Header.Timestamp)
+	}
+	return nil
+}
+
+// checkBlockTimestamp of a block that extends the chain
+func checkBlockTimestamp(chain *Chain, block *Block, now int64) error {
+	if err := checkFutureTimestamp(block, now); err != nil {
+		return err
+	}
+	if chain.size() > 0 {
+		if median := getMedianTimePast(chain); block.Header.Timestamp <= median {
+			return fmt.Errorf("timestamp %v not after median time past %v", block.Header.Timestamp, median)
+		}
+	}
+	return nil
+}
@@ -1,37 +1,306 @@
 package blockchain
 
 import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/ageapps/gambercoin/pkg/logger"
 	"github.com/ageapps/gambercoin/pkg/utils"
 )
 
-// isTransactionValid func
+// UnspentOutput struct
+// Output together with the reference needed to spend it
+type UnspentOutput struct {
+	PrevOut utils.HashValue
+	Index   int
+	Output  Output
+}
+
+// outputLookup returns the output referenced by an input
+type outputLookup func(prevOut utils.HashValue, index int) (*Output, bool)
+
+func outputKey(prevOut utils.HashValue, index int) string {
+	return fmt.Sprintf("%v:%v", prevOut.String(), index)
+}
+
+// isTransactionKnown func
 // check if is in transaction pool or in the canonical chain
-func (bc *BlockChain) isTransactionValid(tx *Transaction) bool {
-	_, ok := bc.getTransactionPool()[tx.String()]
-	if ok {
+func (bc *BlockChain) isTransactionKnown(tx *TransactionMulti) bool {
+	if bc.mempool.Has(tx.String()) {
 		return true
 	}
 	return bc.isTransactionInCanonicalChain(tx)
 }
 
-func (bc *BlockChain) addToTransactionPool(tx *Transaction) utils.HashValue {
+// verifyTransaction checks that every input spends an existing
+// output owned by the key that signed it and that the spent
+// coins are exactly the outputs plus the fee
+func verifyTransaction(tx *TransactionMulti, lookup outputLookup) error {
+	if tx.IsCoinbase() {
+		return errors.New("coinbase transactions are only valid inside a block")
+	}
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return errors.New("transaction needs inputs and outputs")
+	}
+	if tx.Fee < 0 || tx.Fee > MAX_MONEY {
+		return fmt.Errorf("fee %v out of range", tx.Fee)
+	}
+	if tx.LockTime < 0 {
+		return fmt.Errorf("lock time %v is negative", tx.LockTime)
+	}
+	if err := tx.VerifySignature(); err != nil {
+		return err
+	}
+	var err error
+	inputValue := 0
+	spent := make(map[string]bool)
+	for index, in := range tx.Inputs {
+		key := outputKey(in.PrevOut, in.Index)
+		if spent[key] {
+			return fmt.Errorf("input %v spends %v twice", index, key)
+		}
+		spent[key] = true
+		out, found := lookup(in.PrevOut, in.Index)
+		if !found {
+			return fmt.Errorf("input %v spends unknown output %v", index, outputKey(in.PrevOut, in.Index))
+		}
+		// signature was already verified, key is valid
+		pubKey, _ := ParsePubKey(in.PubKey)
+		if GetPubKeyHash(pubKey) != out.PubKeyHash {
+			return fmt.Errorf("input %v is not signed by the owner of %v", index, outputKey(in.PrevOut, in.Index))
+		}
+		if inputValue, err = addMoney(inputValue, out.Value); err != nil {
+			return fmt.Errorf("input %v: %v", index, err)
+		}
+	}
+	outputValue, err := tx.CheckOutputValue()
+	if err != nil {
+		return err
+	}
+	// both are at most MAX_MONEY, the sum does not overflow
+	if outputValue+tx.Fee != inputValue {
+		return fmt.Errorf("outputs spend %v coins with fee %v but inputs hold %v", outputValue, tx.Fee, inputValue)
+	}
+	return nil
+}
+
+// verifyBlockTransactions checks every spend included in the block,
+// transactions can spend outputs created earlier in the same block
+// but an output can only be spent once and every transaction has to be
+// mature for the height and the median time past of the parent, the
+// coinbase at height can only claim the block reward plus the fees
+func (bc *BlockChain) verifyBlockTransactions(block *Block, height int, medianTime int64) error {
+	transactions := block.Body.Transactions
+	if len(transactions) == 0 || !transactions[0].IsCoinbase() {
+		return errors.New("first transaction is not a coinbase")
+	}
+	created := make(map[string]*Output)
+	spent := make(map[string]string)
+	lookup := func(prevOut utils.HashValue, index int) (*Output, bool) {
+		if out, ok := created[outputKey(prevOut, index)]; ok {
+			return out, true
+		}
+		return bc.utxoSet.Get(prevOut, index)
+	}
+	fees := 0
+	var err error
+	for index := range transactions {
+		tx := &transactions[index]
+		if index > 0 && tx.IsCoinbase() {
+			return fmt.Errorf("transaction %v is a second coinbase", tx.String())
+		}
+		if index > 0 {
+			for _, in := range tx.Inputs {
+				key := outputKey(in.PrevOut, in.Index)
+				if spender, ok := spent[key]; ok {
+					return fmt.Errorf("transaction %v double spends %v already spent by %v", tx.String(), key, spender)
+				}
+				spent[key] = tx.String()
+			}
+			if err := verifyTransaction(tx, lookup); err != nil {
+				return fmt.Errorf("transaction %v: %v", tx.String(), err)
+			}
+			if err := checkTransactionLock(tx, height, medianTime); err != nil {
+				return fmt.Errorf("transaction %v: %v", tx.String(), err)
+			}
+			// verified transactions pay fees in the money range
+			if fees, err = addMoney(fees, tx.Fee); err != nil {
+				return fmt.Errorf("fees of the block: %v", err)
+			}
+		}
+		for outIndex := range tx.Outputs {
+			created[outputKey(tx.Name, outIndex)] = &tx.Outputs[outIndex]
+		}
+	}
+	return verifyCoinbase(&transactions[0], height, bc.params.GetBlockReward(height)+fees)
+}
+
+// checkTransactionLock of a transaction included in a block
+// at height whose parent has the median time past
+func checkTransactionLock(tx *TransactionMulti, height int, medianTime int64) error {
+	if tx.IsMature(height, medianTime) {
+		return nil
+	}
+	if lockHeight := tx.GetLockHeight(); lockHeight > 0 {
+		return fmt.Errorf("locked until height %v, block height is %v", lockHeight, height)
+	}
+	return fmt.Errorf("locked until time %v, median time past is %v", tx.LockTime, medianTime)
+}
+
+// getNextBlockLock returns the height and the median time
+// past the transactions of the next block are checked against
+func (bc *BlockChain) getNextBlockLock() (int, int64) {
+	chain := bc.getCanonicalChain()
+	return chain.size(), getMedianTimePast(&chain)
+}
+
+// verifyPoolTransaction checks the transaction against the confirmed
+// outputs and the outputs already spent by transactions in the pool,
+// its lock has to mature within the pool expiry
+func (bc *BlockChain) verifyPoolTransaction(tx *TransactionMulti) error {
+	if err := verifyTransaction(tx, bc.utxoSet.Get); err != nil {
+		return err
+	}
+	height, _ := bc.getNextBlockLock()
+	if err := bc.mempool.CheckLock(tx, height, bc.clock.Now()); err != nil {
+		return err
+	}
+	for _, in := range tx.Inputs {
+		if spender, found := bc.getPoolSpender(in.PrevOut, in.Index); found && spender != tx.String() {
+			return fmt.Errorf("double spend of %v, already spent by %v in pool", outputKey(in.PrevOut, in.Index), spender)
+		}
+	}
+	return nil
+}
+
+// getPoolSpender returns the transaction in the pool spending the output
+func (bc *BlockChain) getPoolSpender(prevOut utils.HashValue, index int) (string, bool) {
+	return bc.mempool.GetSpender(prevOut, index)
+}
+
+// isSpentInPool check if a transaction in the pool spends the output
+func (bc *BlockChain) isSpentInPool(prevOut utils.HashValue, index int) bool {
+	_, found := bc.getPoolSpender(prevOut, index)
+	return found
+}
+
+// conflictsWithPool check if the transaction is already in the pool
+// or spends an output spent by another transaction in the pool
+func (bc *BlockChain) conflictsWithPool(tx *TransactionMulti) bool {
+	for _, in := range tx.Inputs {
+		if bc.isSpentInPool(in.PrevOut, in.Index) {
+			return true
+		}
+	}
+	return false
+}
+
+// revalidateTransactionPool drops the transactions
+// that are not valid anymore after a change of the canonical chain
+func (bc *BlockChain) revalidateTransactionPool() {
+	for _, tx := range bc.mempool.GetTransactions() {
+		if err := verifyTransaction(tx, bc.utxoSet.Get); err != nil {
+			logger.Logw("Transaction %v dropped from pool: %v", tx.String(), err)
+			bc.deleteFromTxPool(tx.String())
+			bc.rejectTransaction(tx, err.Error())
+		}
+	}
+}
+
+// expireTransactionPool drops the transactions
+// that have been waiting too long to be mined
+func (bc *BlockChain) expireTransactionPool() {
+	height, medianTime := bc.getNextBlockLock()
+	for _, tx := range bc.mempool.Expire(time.Now().Unix(), height, medianTime) {
+		logger.Logw("Transaction %v expired in pool", tx.String())
+		bc.rejectTransaction(tx, "expired in the pool")
+		bc.deleteFromStore(tx)
+	}
+}
+
+// CreateTransaction builds a transaction paying amount to the receiver
+// with confirmed outputs owned by the key, the fee is left to the miner
+// and the remaining coins are sent back to the owner of the key
+func (bc *BlockChain) CreateTransaction(key *rsa.PrivateKey, receiver utils.HashValue, amount, fee int) (*TransactionMulti, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount has to be positive")
+	}
+	if fee < 0 {
+		return nil, errors.New("fee can not be negative")
+	}
+	owner := GetPubKeyHash(&key.PublicKey)
+	inputs := []Input{}
+	value := 0
+	for _, unspent := range bc.GetSpendableOutputs(owner) {
+		if value >= amount+fee {
+			break
+		}
+		inputs = append(inputs, Input{PrevOut: unspent.PrevOut, Index: unspent.Index})
+		value += unspent.Output.Value
+	}
+	if value < amount+fee {
+		return nil, fmt.Errorf("not enough funds, %v available", value)
+	}
+	outputs := []Output{Output{PubKeyHash: receiver, Value: amount}}
+	if value > amount+fee {
+		outputs = append(outputs, Output{PubKeyHash: owner, Value: value - amount - fee})
+	}
+	tx := NewTransactionMulti(inputs, outputs, fee)
+	if err := tx.Sign(key); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (bc *BlockChain) addToTransactionPool(tx *TransactionMulti) error {
 	logger.Logb("Storing - %v in TXpool", tx.String())
-	bc.Lock()
-	bc.tansactionPool[tx.String()] = tx
-	bc.Unlock()
-	return tx.Name
+	evicted, err := bc.mempool.Add(tx)
+	if err != nil {
+		return err
+	}
+	for _, evictedTx := range evicted {
+		logger.Logw("Transaction %v evicted from pool", evictedTx.String())
+		bc.rejectTransaction(evictedTx, "evicted from the full pool")
+		bc.deleteFromStore(evictedTx)
+	}
+	if err := bc.store.SaveTransaction(tx); err != nil {
+		logger.Logw("Error storing transaction %v: %v", tx.String(), err)
+	}
+	return nil
 }
 
 func (bc *BlockChain) deleteFromTxPool(hash string) {
-	bc.Lock()
-	delete(bc.tansactionPool, hash)
-	bc.Unlock()
+	if tx, ok := bc.mempool.Remove(hash); ok {
+		bc.deleteFromStore(tx)
+	}
+}
+
+func (bc *BlockChain) deleteFromStore(tx *TransactionMulti) {
+	if err := bc.store.DeleteTransaction(tx.Name); err != nil {
+		logger.Logw("Error storing transaction %v: %v", tx.String(), err)
+	}
 }
 
 func (bc *BlockChain) cleanTransactionPoolByAddedBlock(newBlock *Block) {
 	// Look in tx pool
-	for _, newTx := range newBlock.Transactions {
+	for _, newTx := range newBlock.Body.Transactions {
 		bc.deleteFromTxPool(newTx.String())
+		if newTx.IsCoinbase() {
+			continue
+		}
+		// transactions in the pool spending the same
+		// outputs are now double spends
+		for _, in := range newTx.Inputs {
+			if spender, found := bc.getPoolSpender(in.PrevOut, in.Index); found {
+				reason := fmt.Sprintf("double spend of %v in block %v", outputKey(in.PrevOut, in.Index), newBlock.String())
+				logger.Logw("Transaction %v dropped from pool: %v", spender, reason)
+				if tx, ok := bc.mempool.Get(spender); ok {
+					bc.rejectTransaction(tx, reason)
+				}
+				bc.deleteFromTxPool(spender)
+			}
+		}
 	}
 }
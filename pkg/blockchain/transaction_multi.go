@@ -2,25 +2,194 @@ package blockchain
 
 import (
 	"crypto"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/binary"
+	"errors"
+	"fmt"
 
 	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/dedis/protobuf"
+)
+
+const (
+	// LOCKTIME_THRESHOLD lock times below it are block heights, unix times otherwise
+	LOCKTIME_THRESHOLD = 500000000
+	// MAX_MONEY coins a single value can hold, sums of values
+	// are checked against it so they never overflow
+	MAX_MONEY = 21000000
 )
 
 // TransactionMulti struct
+// Inputs spend outputs of previous transactions
+// Outputs assign coins to the hash of a public key
+// Fee coins of the inputs not assigned to outputs, paid to the miner
+// Data arbitrary bytes, the genesis stores the network id in it
+// and the coinbase of mined blocks the extra nonce
+// LockTime minimum height of the block including the transaction or,
+// from LOCKTIME_THRESHOLD on, minimum median time past of its parent
 type TransactionMulti struct {
-	Input  Input
-	Output Output
-	NumIn  int
-	NumOut int
-	Name   utils.HashValue
+	Inputs   []Input
+	Outputs  []Output
+	Fee      int
+	Data     utils.Bytes
+	Name     utils.HashValue
+	LockTime int64
+}
+
+// NewTransactionMulti creates an unsigned transaction
+func NewTransactionMulti(inputs []Input, outputs []Output, fee int) TransactionMulti {
+	tx := TransactionMulti{
+		Inputs:  inputs,
+		Outputs: outputs,
+		Fee:     fee,
+	}
+	tx.Name = tx.Hash()
+	return tx
+}
+
+// NewCoinbase creates the transaction that pays
+// the miner of a block, the height makes it unique
+func NewCoinbase(minerHash utils.HashValue, amount, height int) TransactionMulti {
+	inputs := []Input{Input{PrevOut: utils.HashValue{}, Index: height}}
+	outputs := []Output{}
+	if amount > 0 {
+		outputs = append(outputs, Output{PubKeyHash: minerHash, Value: amount})
+	}
+	return NewTransactionMulti(inputs, outputs, 0)
+}
+
+// SetExtraNonce stores the extra nonce of the miner in the data
+// of the coinbase, changing it changes the merkle root of the block
+func (tx *TransactionMulti) SetExtraNonce(extraNonce uint64) {
+	tx.Data = make(utils.Bytes, 8)
+	binary.BigEndian.PutUint64(tx.Data, extraNonce)
+	tx.Name = tx.Hash()
+}
+
+// SetLockTime of the transaction, it has to be set before signing it
+func (tx *TransactionMulti) SetLockTime(lockTime int64) {
+	tx.LockTime = lockTime
+	tx.Name = tx.Hash()
+}
+
+// GetLockHeight returns the minimum height of the block
+// including the transaction, 0 if it is not locked by height
+func (tx *TransactionMulti) GetLockHeight() int {
+	if tx.LockTime >= LOCKTIME_THRESHOLD {
+		return 0
+	}
+	return int(tx.LockTime)
+}
+
+// GetLockTimestamp returns the minimum median time past of
+// the block before it, 0 if it is not locked by time
+func (tx *TransactionMulti) GetLockTimestamp() int64 {
+	if tx.LockTime < LOCKTIME_THRESHOLD {
+		return 0
+	}
+	return tx.LockTime
+}
+
+// IsMature check if the transaction can be included in
+// a block at height whose parent has the median time past
+func (tx *TransactionMulti) IsMature(height int, medianTime int64) bool {
+	if tx.LockTime < LOCKTIME_THRESHOLD {
+		return int64(height) >= tx.LockTime
+	}
+	return medianTime >= tx.LockTime
+}
+
+// IsCoinbase check
+func (tx *TransactionMulti) IsCoinbase() bool {
+	return len(tx.Inputs) == 1 && tx.Inputs[0].PrevOut == utils.HashValue{}
+}
+
+// Sign every input of the transaction with the given key
+func (tx *TransactionMulti) Sign(key *rsa.PrivateKey) error {
+	for index := range tx.Inputs {
+		if err := tx.SignInput(index, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SignInput signs the input in index with the given key
+func (tx *TransactionMulti) SignInput(index int, key *rsa.PrivateKey) error {
+	if index < 0 || index >= len(tx.Inputs) {
+		return fmt.Errorf("input %v out of range", index)
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, tx.Name[:])
+	if err != nil {
+		return err
+	}
+	tx.Inputs[index].Signature = signature
+	tx.Inputs[index].PubKey = MarshalPubKey(&key.PublicKey)
+	return nil
 }
 
 // VerifySignature verifies the integrity if the transaction
 func (tx *TransactionMulti) VerifySignature() (err error) {
-	return rsa.VerifyPKCS1v15(tx.Input.PubKey, crypto.SHA256, tx.Name[:], tx.Input.Signature)
+	if tx.Name != tx.Hash() {
+		return errors.New("transaction name does not match its content")
+	}
+	for index, in := range tx.Inputs {
+		if len(in.Signature) == 0 {
+			return fmt.Errorf("input %v is not signed", index)
+		}
+		pubKey, err := ParsePubKey(in.PubKey)
+		if err != nil {
+			return fmt.Errorf("input %v has an invalid public key: %v", index, err)
+		}
+		if err := rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, tx.Name[:], in.Signature); err != nil {
+			return fmt.Errorf("input %v has an invalid signature: %v", index, err)
+		}
+	}
+	return nil
+}
+
+// GetOutputValue func
+func (tx *TransactionMulti) GetOutputValue() int {
+	value := 0
+	for _, out := range tx.Outputs {
+		value += out.Value
+	}
+	return value
+}
+
+// CheckOutputValue returns the coins of the outputs, every output
+// has to be positive and the sum can not go over MAX_MONEY
+func (tx *TransactionMulti) CheckOutputValue() (int, error) {
+	value := 0
+	for index, out := range tx.Outputs {
+		if out.Value <= 0 {
+			return 0, fmt.Errorf("output %v has no value", index)
+		}
+		var err error
+		if value, err = addMoney(value, out.Value); err != nil {
+			return 0, fmt.Errorf("output %v: %v", index, err)
+		}
+	}
+	return value, nil
+}
+
+// addMoney adds value to total, both have to be in the money range
+func addMoney(total, value int) (int, error) {
+	if value < 0 || value > MAX_MONEY || total > MAX_MONEY-value {
+		return 0, fmt.Errorf("value %v over the maximum of %v coins", value, MAX_MONEY)
+	}
+	return total + value, nil
+}
+
+// GetSize returns the bytes of the encoded transaction
+func (tx *TransactionMulti) GetSize() int {
+	packet, err := protobuf.Encode(tx)
+	if err != nil {
+		return 0
+	}
+	return len(packet)
 }
 
 // AppendTransaction func
@@ -35,16 +204,16 @@ type Input struct {
 	PrevOut   utils.HashValue
 	Index     int
 	Signature utils.Bytes
-	PubKey    *rsa.PublicKey
+	PubKey    utils.Bytes
 }
 
-// Hash input
+// Hash input, the signature is not included
+// since it is computed over the transaction hash
 func (in *Input) Hash() (out []byte) {
 	h := sha256.New()
+	h.Write(in.PrevOut[:])
 	binary.Write(h, binary.LittleEndian, uint32(in.Index))
-	h.Write(in.Signature)
-	copy(out[:], h.Sum(nil))
-	return
+	return h.Sum(nil)
 }
 
 // Output struct
@@ -55,14 +224,32 @@ type Output struct {
 	Value      int
 }
 
+// Hash output
+func (out *Output) Hash() []byte {
+	h := sha256.New()
+	h.Write(out.PubKeyHash[:])
+	binary.Write(h, binary.LittleEndian, uint64(out.Value))
+	return h.Sum(nil)
+}
+
 // Hash transaction
 func (tx *TransactionMulti) Hash() (out [32]byte) {
 	h := sha256.New()
-	binary.Write(h, binary.LittleEndian, uint32(tx.NumIn))
-	binary.Write(h, binary.LittleEndian, uint32(tx.NumOut))
-	h.Write(tx.Name[:])
-	h.Write(tx.Input.Hash())
-	h.Write(tx.Output.PubKeyHash[:])
+	binary.Write(h, binary.LittleEndian, uint32(len(tx.Inputs)))
+	binary.Write(h, binary.LittleEndian, uint32(len(tx.Outputs)))
+	for _, in := range tx.Inputs {
+		h.Write(in.Hash())
+	}
+	for _, output := range tx.Outputs {
+		h.Write(output.Hash())
+	}
+	binary.Write(h, binary.LittleEndian, uint64(tx.Fee))
+	binary.Write(h, binary.LittleEndian, uint32(len(tx.Data)))
+	h.Write(tx.Data)
+	if tx.LockTime != 0 {
+		// names of transactions without lock stay the same
+		binary.Write(h, binary.LittleEndian, uint64(tx.LockTime))
+	}
 	copy(out[:], h.Sum(nil))
 	return
 }
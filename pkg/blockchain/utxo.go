@@ -0,0 +1,142 @@
+package blockchain
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// UTXOSet struct
+// Index of the unspent outputs of the canonical chain,
+// it keeps the outputs spent by every applied block
+// so that they can be restored when the block is rolled back
+type UTXOSet struct {
+	outputs map[string]UnspentOutput
+	owners  map[utils.HashValue]map[string]bool
+	undo    map[string]map[string]UnspentOutput
+	mux     sync.Mutex
+}
+
+// NewUTXOSet func
+func NewUTXOSet() *UTXOSet {
+	return &UTXOSet{
+		outputs: make(map[string]UnspentOutput),
+		owners:  make(map[utils.HashValue]map[string]bool),
+		undo:    make(map[string]map[string]UnspentOutput),
+	}
+}
+
+// ApplyBlock spends the inputs and adds the outputs
+// of every transaction in the block
+func (set *UTXOSet) ApplyBlock(block *Block) {
+	set.mux.Lock()
+	defer set.mux.Unlock()
+	spent := make(map[string]UnspentOutput)
+	for _, tx := range block.Body.Transactions {
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Inputs {
+				key := outputKey(in.PrevOut, in.Index)
+				if unspent, ok := set.outputs[key]; ok {
+					spent[key] = unspent
+					set.remove(key)
+				}
+			}
+		}
+		for index, out := range tx.Outputs {
+			set.add(UnspentOutput{PrevOut: tx.Name, Index: index, Output: out})
+		}
+	}
+	set.undo[block.String()] = spent
+}
+
+// RollbackBlock removes the outputs created by the block
+// and restores the ones it spent
+func (set *UTXOSet) RollbackBlock(block *Block) {
+	set.mux.Lock()
+	defer set.mux.Unlock()
+	spent := set.undo[block.String()]
+	for txIndex := len(block.Body.Transactions) - 1; txIndex >= 0; txIndex-- {
+		tx := block.Body.Transactions[txIndex]
+		for index := range tx.Outputs {
+			set.remove(outputKey(tx.Name, index))
+		}
+		if tx.IsCoinbase() {
+			continue
+		}
+		for _, in := range tx.Inputs {
+			if unspent, ok := spent[outputKey(in.PrevOut, in.Index)]; ok {
+				set.add(unspent)
+			}
+		}
+	}
+	delete(set.undo, block.String())
+}
+
+// Get the unspent output referenced by an input
+func (set *UTXOSet) Get(prevOut utils.HashValue, index int) (*Output, bool) {
+	set.mux.Lock()
+	defer set.mux.Unlock()
+	unspent, ok := set.outputs[outputKey(prevOut, index)]
+	if !ok {
+		return nil, false
+	}
+	return &unspent.Output, true
+}
+
+// GetUnspentOutputs owned by the hash
+func (set *UTXOSet) GetUnspentOutputs(hash utils.HashValue) []UnspentOutput {
+	set.mux.Lock()
+	defer set.mux.Unlock()
+	keys := []string{}
+	for key := range set.owners[hash] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	unspent := []UnspentOutput{}
+	for _, key := range keys {
+		unspent = append(unspent, set.outputs[key])
+	}
+	return unspent
+}
+
+// GetBalance of the hash
+func (set *UTXOSet) GetBalance(hash utils.HashValue) int {
+	set.mux.Lock()
+	defer set.mux.Unlock()
+	balance := 0
+	for key := range set.owners[hash] {
+		balance += set.outputs[key].Output.Value
+	}
+	return balance
+}
+
+// Size number of unspent outputs
+func (set *UTXOSet) Size() int {
+	set.mux.Lock()
+	defer set.mux.Unlock()
+	return len(set.outputs)
+}
+
+func (set *UTXOSet) add(unspent UnspentOutput) {
+	key := outputKey(unspent.PrevOut, unspent.Index)
+	set.outputs[key] = unspent
+	owner := unspent.Output.PubKeyHash
+	if _, ok := set.owners[owner]; !ok {
+		set.owners[owner] = make(map[string]bool)
+	}
+	set.owners[owner][key] = true
+}
+
+func (set *UTXOSet) remove(key string) {
+	unspent, ok := set.outputs[key]
+	if !ok {
+		return
+	}
+	delete(set.outputs, key)
+	owner := unspent.Output.PubKeyHash
+	delete(set.owners[owner], key)
+	if len(set.owners[owner]) == 0 {
+		delete(set.owners, owner)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// API client of the HTTP server of a node
+// URL of the server and Name of the node in it
+type API struct {
+	URL  string
+	Name string
+	http *http.Client
+}
+
+// NewAPI returns a client of the node name served in url
+func NewAPI(url, name string) *API {
+	return &API{
+		URL:  strings.TrimSuffix(url, "/"),
+		Name: name,
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetWallet decodes the wallet of the node in v
+func (api *API) GetWallet(v interface{}) error {
+	return api.get("/wallet", url.Values{}, v)
+}
+
+// NewAddress creates an address in the wallet of the node
+func (api *API) NewAddress() (string, error) {
+	response := struct {
+		Address string `json:"address"`
+	}{}
+	err := api.post("/wallet/address", map[string]interface{}{}, &response)
+	return response.Address, err
+}
+
+// GetBalance of an address
+func (api *API) GetBalance(address string) (int, error) {
+	balance := 0
+	err := api.get("/balance", url.Values{"hash": {address}}, &balance)
+	return balance, err
+}
+
+// GetHistory decodes the transactions of an address in v
+func (api *API) GetHistory(address string, v interface{}) error {
+	return api.get("/address/"+url.PathEscape(address)+"/history", url.Values{}, v)
+}
+
+// EstimateFee decodes the fee estimate of a transaction mined within blocks in v
+func (api *API) EstimateFee(blocks int, v interface{}) error {
+	return api.get("/fee/estimate", url.Values{"blocks": {strconv.Itoa(blocks)}}, v)
+}
+
+// SendTransaction signed by the node with the key of the from address,
+// any wallet address if empty, the fee is estimated if negative
+// and a LockTime delays it until a block height or median time.
+// The name of the transaction is returned to follow its status
+func (api *API) SendTransaction(tx ClientTx) (string, error) {
+	response := struct {
+		Tx string `json:"tx"`
+	}{}
+	err := api.post("/transaction", map[string]interface{}{
+		"in":       tx.In,
+		"out":      tx.Out,
+		"amount":   tx.Amount,
+		"fee":      tx.Fee,
+		"lockTime": tx.LockTime,
+	}, &response)
+	return response.Tx, err
+}
+
+// GetTransactionStatus decodes the status of a transaction in v
+func (api *API) GetTransactionStatus(hash string, v interface{}) error {
+	return api.get("/tx/"+url.PathEscape(hash)+"/status", url.Values{}, v)
+}
+
+// GetNotifications decodes in v the notifications of an address
+// of the node wallet, all the addresses if empty, newer than since
+func (api *API) GetNotifications(address string, since uint64, v interface{}) error {
+	query := url.Values{"since": {strconv.FormatUint(since, 10)}}
+	if address != "" {
+		query.Set("address", address)
+	}
+	return api.get("/wallet/notifications", query, v)
+}
+
+func (api *API) get(path string, query url.Values, v interface{}) error {
+	query.Set("name", api.Name)
+	response, err := api.http.Get(api.URL + path + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	return decodeResponse(response, v)
+}
+
+func (api *API) post(path string, body map[string]interface{}, v interface{}) error {
+	body["name"] = api.Name
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	response, err := api.http.Post(api.URL+path, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	return decodeResponse(response, v)
+}
+
+// decodeResponse in v, the server answers errors as plain text
+func decodeResponse(response *http.Response, v interface{}) error {
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v: %v", response.Status, strings.TrimSpace(string(content)))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(content, v)
+}
@@ -1,10 +1,5 @@
 package client
 
-import (
-	"github.com/ageapps/gambercoin/pkg/blockchain"
-	"github.com/ageapps/gambercoin/pkg/utils"
-)
-
 // Message to send
 type Message struct {
 	Text        string
@@ -14,16 +9,16 @@ type Message struct {
 }
 
 // ClientTx to send
+// In address of the node wallet paying, the node
+// selects outputs of any wallet address if empty
+// Fee coins paid to the miner on top of the amount,
+// estimated by the node if negative
 type ClientTx struct {
-	In     string
-	Out    string
-	Amount int
-}
-
-func (tx *ClientTx) GetTransaction() blockchain.Transaction {
-	in, _ := utils.GetHash(tx.In)
-	out, _ := utils.GetHash(tx.Out)
-	return blockchain.NewTransaction(in, out, tx.Amount)
+	In       string
+	Out      string
+	Amount   int
+	Fee      int
+	LockTime int64
 }
 
 // IsDirectMessage check if is private message
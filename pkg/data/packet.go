@@ -21,6 +21,16 @@ const (
 	PACKET_TX = "TX_PUBLISH"
 	// PACKET_BLOCK type
 	PACKET_BLOCK = "BLOCK_PUBLISH"
+	// PACKET_TIP_REQUEST type
+	PACKET_TIP_REQUEST = "TIP_REQUEST"
+	// PACKET_TIP_REPLY type
+	PACKET_TIP_REPLY = "TIP_REPLY"
+	// PACKET_HASHES_REQUEST type
+	PACKET_HASHES_REQUEST = "HASHES_REQUEST"
+	// PACKET_HASHES_REPLY type
+	PACKET_HASHES_REPLY = "HASHES_REPLY"
+	// PACKET_BLOCKS_REQUEST type
+	PACKET_BLOCKS_REQUEST = "BLOCKS_REQUEST"
 )
 
 // UDPMessage struct
@@ -32,12 +42,17 @@ type UDPMessage struct {
 
 // GossipPacket struct
 type GossipPacket struct {
-	Simple       *SimpleMessage
-	Rumor        *monguer.RumorMessage
-	Status       *monguer.StatusPacket
-	Private      *PrivateMessage
-	TxMessage    *blockchain.TxMessage
-	BlockMessage *blockchain.BlockMessage
+	Simple        *SimpleMessage
+	Rumor         *monguer.RumorMessage
+	Status        *monguer.StatusPacket
+	Private       *PrivateMessage
+	TxMessage     *blockchain.TxMessage
+	BlockMessage  *blockchain.BlockMessage
+	TipRequest    *blockchain.ChainTip
+	TipReply      *blockchain.ChainTip
+	HashesRequest *blockchain.HashesRequest
+	HashesReply   *blockchain.HashesReply
+	BlocksRequest *blockchain.BlocksRequest
 }
 
 // GetPacketType function
@@ -49,6 +64,11 @@ func (packet *GossipPacket) GetPacketType() string {
 		PACKET_PRIVATE,
 		PACKET_TX,
 		PACKET_BLOCK,
+		PACKET_TIP_REQUEST,
+		PACKET_TIP_REPLY,
+		PACKET_HASHES_REQUEST,
+		PACKET_HASHES_REPLY,
+		PACKET_BLOCKS_REQUEST,
 	}
 	var values []interface{}
 	values = append(values, packet.Simple)
@@ -57,6 +77,11 @@ func (packet *GossipPacket) GetPacketType() string {
 	values = append(values, packet.Private)
 	values = append(values, packet.TxMessage)
 	values = append(values, packet.BlockMessage)
+	values = append(values, packet.TipRequest)
+	values = append(values, packet.TipReply)
+	values = append(values, packet.HashesRequest)
+	values = append(values, packet.HashesReply)
+	values = append(values, packet.BlocksRequest)
 
 	notNull := -1
 
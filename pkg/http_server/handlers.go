@@ -9,9 +9,12 @@ import (
 	"log"
 	"net/http"
 	"reflect"
+	"strconv"
 
+	"github.com/ageapps/gambercoin/pkg/blockchain"
 	"github.com/ageapps/gambercoin/pkg/utils"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 )
 
 // Health message
@@ -121,22 +124,32 @@ func PostTransaction(w http.ResponseWriter, r *http.Request) {
 		sendError(&w, errors.New("Error: no name requested"))
 		return
 	}
-	in, ok := params["in"].(string)
-	if !ok {
-		sendError(&w, errors.New("Error: no in requested"))
-		return
-	}
+	// in is optional, the node selects the outputs of its wallet
+	in, _ := params["in"].(string)
 	out, ok := params["out"].(string)
 	if !ok {
 		sendError(&w, errors.New("Error: no out requested"))
 		return
 	}
-	amount, ok := params["amount"].(int)
+	// json numbers are always decoded as float64
+	amount, ok := params["amount"].(float64)
 	if !ok {
 		sendError(&w, errors.New("Error: no amount requested"))
 		return
 	}
-	send(&w, sendTransaction(name, in, out, amount))
+	// fee is optional, the node estimates it
+	fee, ok := params["fee"].(float64)
+	if !ok {
+		fee = -1
+	}
+	// lockTime is optional, the transaction is not locked
+	lockTime, _ := params["lockTime"].(float64)
+	hash, err := sendTransaction(name, in, out, int(amount), int(fee), int64(lockTime))
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, map[string]string{"tx": hash})
 }
 
 // GetID func
@@ -170,6 +183,360 @@ func GetBalance(w http.ResponseWriter, r *http.Request) {
 	send(&w, geetHashBalance(name, hash))
 }
 
+// GetStats of the chain and miner of a node
+func GetStats(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for stats"))
+		return
+	}
+	send(&w, getNodeStats(name))
+}
+
+// GetMining status of a node
+func GetMining(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for mining"))
+		return
+	}
+	send(&w, getNodeMiningStats(name))
+}
+
+// PostMiningStart turns on the miner of a node
+func PostMiningStart(w http.ResponseWriter, r *http.Request) {
+	postMining(w, r, true)
+}
+
+// PostMiningStop turns off the miner of a node
+func PostMiningStop(w http.ResponseWriter, r *http.Request) {
+	postMining(w, r, false)
+}
+
+func postMining(w http.ResponseWriter, r *http.Request, enabled bool) {
+	params := *readBody(&w, r)
+	name, ok := params["name"].(string)
+	if !ok || !setNodeMining(name, enabled) {
+		sendError(&w, errors.New("Error: no peer requested"))
+		return
+	}
+	send(&w, getNodeMiningStats(name))
+}
+
+// PostMiningAddress sets where the rewards of a node are paid,
+// either an address or a hex encoded private key
+func PostMiningAddress(w http.ResponseWriter, r *http.Request) {
+	params := *readBody(&w, r)
+	name, ok := params["name"].(string)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested"))
+		return
+	}
+	if keyStr, ok := params["key"].(string); ok {
+		var keyBytes utils.Bytes
+		if err := keyBytes.Set(keyStr); err != nil {
+			sendError(&w, errors.New("Error: bad key conversion"))
+			return
+		}
+		key, err := blockchain.ParsePrivateKey(keyBytes)
+		if err != nil {
+			sendError(&w, fmt.Errorf("Error: key not valid, %v", err))
+			return
+		}
+		if err := setNodeMinerKey(name, key); err != nil {
+			sendError(&w, err)
+			return
+		}
+	} else {
+		address, found := params["address"].(string)
+		if !found {
+			sendError(&w, errors.New("Error: no address or key requested"))
+			return
+		}
+		hash, err := utils.GetHash(address)
+		if err != nil {
+			sendError(&w, errors.New("Error: bad hash conversion"))
+			return
+		}
+		ok = setNodeRewardAddress(name, hash)
+	}
+	if !ok {
+		sendError(&w, errors.New("Error: peer not found"))
+		return
+	}
+	send(&w, getNodeMiningStats(name))
+}
+
+// PostMiningThreads sets the threads of the miner of a node
+func PostMiningThreads(w http.ResponseWriter, r *http.Request) {
+	params := *readBody(&w, r)
+	name, ok := params["name"].(string)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested"))
+		return
+	}
+	threads, ok := params["threads"].(float64)
+	if !ok {
+		sendError(&w, errors.New("Error: no threads requested"))
+		return
+	}
+	if err := setNodeMiningThreads(name, int(threads)); err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, getNodeMiningStats(name))
+}
+
+// GetBlocks returns a page of the canonical chain of a node
+func GetBlocks(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for blocks"))
+		return
+	}
+	from, err := getIntFromRequest(r, "from", 0)
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	limit, err := getIntFromRequest(r, "limit", blockchain.MAX_EXPLORER_BLOCKS)
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, getNodeBlocks(name, from, limit))
+}
+
+// GetBlock by hash
+func GetBlock(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for block"))
+		return
+	}
+	block := getNodeBlock(name, mux.Vars(r)["hash"])
+	if block == nil {
+		sendError(&w, errors.New("Error: block not found"))
+		return
+	}
+	send(&w, block)
+}
+
+// GetBlockAtHeight of the canonical chain
+func GetBlockAtHeight(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for block"))
+		return
+	}
+	height, err := strconv.Atoi(mux.Vars(r)["height"])
+	if err != nil {
+		sendError(&w, errors.New("Error: bad height conversion"))
+		return
+	}
+	block := getNodeBlockAtHeight(name, height)
+	if block == nil {
+		sendError(&w, errors.New("Error: block not found"))
+		return
+	}
+	send(&w, block)
+}
+
+// GetTransaction by hash with its confirmations
+func GetTransaction(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for transaction"))
+		return
+	}
+	tx := getNodeTransaction(name, mux.Vars(r)["hash"])
+	if tx == nil {
+		sendError(&w, errors.New("Error: transaction not found"))
+		return
+	}
+	send(&w, tx)
+}
+
+// GetTransactionStatus of a transaction seen by a node
+func GetTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for transaction status"))
+		return
+	}
+	status := getNodeTransactionStatus(name, mux.Vars(r)["hash"])
+	if status == nil {
+		sendError(&w, errors.New("Error: peer not found"))
+		return
+	}
+	send(&w, status)
+}
+
+// GetAddressHistory of the credits and debits of an address
+func GetAddressHistory(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for history"))
+		return
+	}
+	address, err := utils.GetHash(mux.Vars(r)["hash"])
+	if err != nil {
+		sendError(&w, errors.New("Error: bad hash conversion"))
+		return
+	}
+	send(&w, getNodeAddressHistory(name, address))
+}
+
+// GetFeeEstimate of a transaction mined within the requested blocks
+func GetFeeEstimate(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for fee estimate"))
+		return
+	}
+	blocks, err := getIntFromRequest(r, "blocks", blockchain.DEFAULT_FEE_TARGET)
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, getNodeFeeEstimate(name, blocks))
+}
+
+// GetMempool lists the pending transactions of a node
+func GetMempool(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for mempool"))
+		return
+	}
+	send(&w, getNodePendingTransactions(name))
+}
+
+// GetForks lists the side chain and orphan blocks of a node
+func GetForks(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for forks"))
+		return
+	}
+	send(&w, getNodeForkBlocks(name))
+}
+
+// GetReorgs lists the last reorganizations of a node
+func GetReorgs(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for reorgs"))
+		return
+	}
+	send(&w, getNodeReorgs(name))
+}
+
+// PostCreateRawTransaction builds an unsigned transaction spending
+// the chosen outputs, it has to be signed and sent to /tx/raw
+func PostCreateRawTransaction(w http.ResponseWriter, r *http.Request) {
+	params := *readBody(&w, r)
+	name, ok := params["name"].(string)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested"))
+		return
+	}
+	inputs, err := getInputsFromBody(params)
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	outputs, err := getOutputsFromBody(params)
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	lockTime, _ := params["lockTime"].(float64)
+	unsigned, err := createNodeRawTransaction(name, inputs, outputs, int64(lockTime))
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, unsigned)
+}
+
+// PostRawTransaction verifies a hex encoded signed transaction and relays it
+func PostRawTransaction(w http.ResponseWriter, r *http.Request) {
+	params := *readBody(&w, r)
+	name, ok := params["name"].(string)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested"))
+		return
+	}
+	raw, ok := params["tx"].(string)
+	if !ok {
+		sendError(&w, errors.New("Error: no tx requested"))
+		return
+	}
+	tx, err := blockchain.DecodeTransaction(raw)
+	if err != nil {
+		sendError(&w, fmt.Errorf("Error: tx not valid, %v", err))
+		return
+	}
+	if err := sendNodeRawTransaction(name, tx); err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, map[string]string{"tx": tx.String()})
+}
+
+// GetWallet lists the addresses of the wallet of a node with their balances
+func GetWallet(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for wallet"))
+		return
+	}
+	wallet := getNodeWallet(name)
+	if wallet == nil {
+		sendError(&w, errors.New("Error: peer not found"))
+		return
+	}
+	send(&w, wallet)
+}
+
+// GetWalletNotifications of the payments of the wallet of a node,
+// address selects one wallet address and since skips older notifications
+func GetWalletNotifications(w http.ResponseWriter, r *http.Request) {
+	name, ok := getNameFromRequest(r)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested for notifications"))
+		return
+	}
+	since, err := getIntFromRequest(r, "since", 0)
+	if err != nil || since < 0 {
+		sendError(&w, errors.New("Error: bad since conversion"))
+		return
+	}
+	notifications, err := getNodeWalletNotifications(name, r.URL.Query().Get("address"), uint64(since))
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, notifications)
+}
+
+// PostWalletAddress creates a new address in the wallet of a node
+func PostWalletAddress(w http.ResponseWriter, r *http.Request) {
+	params := *readBody(&w, r)
+	name, ok := params["name"].(string)
+	if !ok {
+		sendError(&w, errors.New("Error: no peer requested"))
+		return
+	}
+	address, err := newNodeWalletAddress(name)
+	if err != nil {
+		sendError(&w, err)
+		return
+	}
+	send(&w, map[string]string{"address": address.String()})
+}
+
 // Delete node
 func Delete(w http.ResponseWriter, r *http.Request) {
 	params := *readBody(&w, r)
@@ -271,6 +638,67 @@ func getNameFromRequest(r *http.Request) (string, bool) {
 	}
 	return name[0], true
 }
+
+// getIntFromRequest returns the query parameter
+// as an int or the default value if it is missing
+func getIntFromRequest(r *http.Request, param string, defaultValue int) (int, error) {
+	value, ok := r.URL.Query()[param]
+	if !ok || len(value[0]) < 1 {
+		return defaultValue, nil
+	}
+	number, err := strconv.Atoi(value[0])
+	if err != nil {
+		return 0, fmt.Errorf("Error: bad %v conversion", param)
+	}
+	return number, nil
+}
+
+// getInputsFromBody reads the [{prevOut, index}] inputs of a transaction
+func getInputsFromBody(params map[string]interface{}) ([]blockchain.Input, error) {
+	list, ok := params["inputs"].([]interface{})
+	if !ok {
+		return nil, errors.New("Error: no inputs requested")
+	}
+	inputs := []blockchain.Input{}
+	for _, item := range list {
+		input, _ := item.(map[string]interface{})
+		prevOutStr, ok := input["prevOut"].(string)
+		index, found := input["index"].(float64)
+		if !ok || !found {
+			return nil, errors.New("Error: inputs need prevOut and index")
+		}
+		prevOut, err := utils.GetHash(prevOutStr)
+		if err != nil {
+			return nil, errors.New("Error: bad hash conversion")
+		}
+		inputs = append(inputs, blockchain.Input{PrevOut: prevOut, Index: int(index)})
+	}
+	return inputs, nil
+}
+
+// getOutputsFromBody reads the [{address, value}] outputs of a transaction
+func getOutputsFromBody(params map[string]interface{}) ([]blockchain.Output, error) {
+	list, ok := params["outputs"].([]interface{})
+	if !ok {
+		return nil, errors.New("Error: no outputs requested")
+	}
+	outputs := []blockchain.Output{}
+	for _, item := range list {
+		output, _ := item.(map[string]interface{})
+		addressStr, ok := output["address"].(string)
+		value, found := output["value"].(float64)
+		if !ok || !found {
+			return nil, errors.New("Error: outputs need address and value")
+		}
+		address, err := utils.GetHash(addressStr)
+		if err != nil {
+			return nil, errors.New("Error: bad hash conversion")
+		}
+		outputs = append(outputs, blockchain.Output{PubKeyHash: address, Value: int(value)})
+	}
+	return outputs, nil
+}
+
 func getHashFromRequest(r *http.Request) (string, bool) {
 	name, ok := r.URL.Query()["hash"]
 	if !ok || len(name[0]) < 1 {
@@ -1,17 +1,21 @@
 package http_server
 
 import (
+	"crypto/rsa"
+	"errors"
 	"log"
 	"strings"
 	"sync"
 
 	"github.com/ageapps/gambercoin/pkg/stack"
 
+	"github.com/ageapps/gambercoin/pkg/blockchain"
 	"github.com/ageapps/gambercoin/pkg/client"
 	"github.com/ageapps/gambercoin/pkg/logger"
 	"github.com/ageapps/gambercoin/pkg/node"
 	"github.com/ageapps/gambercoin/pkg/router"
 	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/ageapps/gambercoin/pkg/wallet"
 )
 
 func NewNodePool() *NodePool {
@@ -71,6 +75,26 @@ var (
 	nodePool = NewNodePool()
 	// DebugLevel default level
 	DebugLevel = logger.Info
+	// DataDir where nodes store their chain
+	DataDir = ""
+	// Network preset or params file nodes join
+	Network = blockchain.DEFAULT_NETWORK
+	// Mempool limits of the nodes transaction pool
+	Mempool = blockchain.DefaultMempoolConfig()
+	// Consensus engine nodes seal blocks with
+	Consensus = blockchain.CONSENSUS_POW
+	// SealInterval of the interval consensus engine
+	SealInterval = int64(blockchain.DEFAULT_SEAL_INTERVAL)
+	// MiningThreads of the proof of work engine of the nodes
+	MiningThreads = 1
+	// DisableMining of the new nodes
+	DisableMining = false
+	// WalletPassword encrypting the keystores of the nodes
+	WalletPassword = ""
+	// CoinSelection strategy of the node wallets
+	CoinSelection = wallet.SELECTION_BRANCH_AND_BOUND
+	// DustThreshold change left to the miner by the node wallets
+	DustThreshold = wallet.DEFAULT_DUST_THRESHOLD
 )
 
 // StatusResponse struct
@@ -87,7 +111,18 @@ func startNode(name, address string, peers *utils.PeerAddresses) string {
 	targetNode, found := nodePool.findNode(name, address)
 
 	if !found {
-		newNode, err := node.NewNode(address, name)
+		newNode, err := node.NewNode(address, name, node.Config{
+			DataDir:        DataDir,
+			Network:        Network,
+			Mempool:        Mempool,
+			Consensus:      Consensus,
+			SealInterval:   SealInterval,
+			MiningThreads:  MiningThreads,
+			DisableMining:  DisableMining,
+			WalletPassword: WalletPassword,
+			CoinSelection:  CoinSelection,
+			DustThreshold:  DustThreshold,
+		})
 		if err != nil {
 			logger.Logw("Error creating new Node, %v ", err)
 			return ""
@@ -164,6 +199,167 @@ func getStatusResponse(name string) *StatusResponse {
 	}
 }
 
+func getNodeStats(name string) *node.Stats {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	stats := targetNode.GetStats()
+	return &stats
+}
+
+func getNodeMiningStats(name string) *blockchain.MiningStats {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	stats := targetNode.GetMiningStats()
+	return &stats
+}
+
+func setNodeMining(name string, enabled bool) bool {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return false
+	}
+	targetNode.SetMining(enabled)
+	return true
+}
+
+func setNodeRewardAddress(name string, hash utils.HashValue) bool {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return false
+	}
+	targetNode.SetRewardAddress(hash)
+	return true
+}
+
+func setNodeMinerKey(name string, key *rsa.PrivateKey) error {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return errors.New("node not found")
+	}
+	return targetNode.SetMinerKey(key)
+}
+
+func setNodeMiningThreads(name string, threads int) error {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return errors.New("node not found")
+	}
+	return targetNode.SetMiningThreads(threads)
+}
+
+func getNodeBlocks(name string, from, limit int) []*blockchain.BlockView {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	return targetNode.GetBlocks(from, limit)
+}
+
+func getNodeBlock(name, hash string) *blockchain.BlockView {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	block, _ := targetNode.GetBlock(hash)
+	return block
+}
+
+func getNodeBlockAtHeight(name string, height int) *blockchain.BlockView {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	block, _ := targetNode.GetBlockAtHeight(height)
+	return block
+}
+
+func getNodeTransaction(name, hash string) *blockchain.TransactionInfo {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	tx, _ := targetNode.GetTransaction(hash)
+	return tx
+}
+
+func getNodeAddressHistory(name string, address utils.HashValue) []blockchain.AddressEntry {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	return targetNode.GetAddressHistory(address)
+}
+
+func getNodePendingTransactions(name string) []blockchain.PendingTransaction {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	return targetNode.GetPendingTransactions()
+}
+
+func getNodeForkBlocks(name string) []blockchain.ForkBlock {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	return targetNode.GetForkBlocks()
+}
+
+func getNodeReorgs(name string) []blockchain.Reorg {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	return targetNode.GetReorgs()
+}
+
+func createNodeRawTransaction(name string, inputs []blockchain.Input, outputs []blockchain.Output, lockTime int64) (*blockchain.UnsignedTransaction, error) {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil, errors.New("node not found")
+	}
+	return targetNode.CreateRawTransaction(inputs, outputs, lockTime)
+}
+
+func sendNodeRawTransaction(name string, tx *blockchain.TransactionMulti) error {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return errors.New("node not found")
+	}
+	return targetNode.SendRawTransaction(tx)
+}
+
+func getNodeFeeEstimate(name string, blocks int) *blockchain.FeeEstimate {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	estimate := targetNode.EstimateFee(blocks)
+	return &estimate
+}
+
+func getNodeWallet(name string) *node.WalletInfo {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
+	}
+	info := targetNode.GetWallet()
+	return &info
+}
+
+func newNodeWalletAddress(name string) (utils.HashValue, error) {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return utils.HashValue{}, errors.New("node not found")
+	}
+	return targetNode.NewWalletAddress()
+}
+
 func deleteNode(name string) {
 	targetNode, found := nodePool.getNode(name)
 	if !found {
@@ -209,15 +405,27 @@ func sendPrivateMessage(name, destination, msg string) bool {
 	return true
 }
 
-func sendTransaction(name, in, out string, amount int) bool {
+func sendTransaction(name, in, out string, amount, fee int, lockTime int64) (string, error) {
 	targetNode, found := nodePool.getNode(name)
 	if !found {
-		return false
+		return "", errors.New("node not found")
 	}
-	newMsg := &client.Message{
-		Transaction: &client.ClientTx{in, out, amount},
+	return targetNode.SendTransaction(&client.ClientTx{In: in, Out: out, Amount: amount, Fee: fee, LockTime: lockTime})
+}
+
+func getNodeTransactionStatus(name, hash string) *blockchain.TransactionStatus {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil
 	}
-	channel, _ := nodePool.getMsgChannel(targetNode.Name)
-	channel <- *newMsg
-	return true
+	status := targetNode.GetTransactionStatus(hash)
+	return &status
+}
+
+func getNodeWalletNotifications(name, address string, since uint64) ([]blockchain.Notification, error) {
+	targetNode, found := nodePool.getNode(name)
+	if !found {
+		return nil, errors.New("node not found")
+	}
+	return targetNode.GetWalletNotifications(address, since)
 }
@@ -21,6 +21,12 @@ var routes = Routes{
 	Route{"Nodes", "GET", "/node", GetNodes},
 	Route{"ID", "GET", "/id", GetID},
 	Route{"Health", "GET", "/health", Health},
+	Route{"Stats", "GET", "/stats", GetStats},
+	Route{"Mining", "GET", "/mining", GetMining},
+	Route{"Mining Start", "POST", "/mining/start", PostMiningStart},
+	Route{"Mining Stop", "POST", "/mining/stop", PostMiningStop},
+	Route{"Mining Address", "POST", "/mining/address", PostMiningAddress},
+	Route{"Mining Threads", "POST", "/mining/threads", PostMiningThreads},
 	Route{"Messages", "POST", "/message", PostMessage},
 	Route{"Nodes", "POST", "/node", PostNode},
 	Route{"Private Message", "POST", "/private", PostPrivateMessage},
@@ -28,6 +34,21 @@ var routes = Routes{
 	Route{"Delete", "POST", "/delete", Delete},
 	Route{"Delete", "GET", "/balance", GetBalance},
 	Route{"Delete", "POST", "/transaction", PostTransaction},
+	Route{"Blocks", "GET", "/blocks", GetBlocks},
+	Route{"Block Height", "GET", "/block/height/{height}", GetBlockAtHeight},
+	Route{"Block", "GET", "/block/{hash}", GetBlock},
+	Route{"Transaction", "GET", "/tx/{hash}", GetTransaction},
+	Route{"Transaction Status", "GET", "/tx/{hash}/status", GetTransactionStatus},
+	Route{"Create Raw Transaction", "POST", "/tx/create", PostCreateRawTransaction},
+	Route{"Raw Transaction", "POST", "/tx/raw", PostRawTransaction},
+	Route{"Address History", "GET", "/address/{hash}/history", GetAddressHistory},
+	Route{"Mempool", "GET", "/mempool", GetMempool},
+	Route{"Fee Estimate", "GET", "/fee/estimate", GetFeeEstimate},
+	Route{"Forks", "GET", "/forks", GetForks},
+	Route{"Reorgs", "GET", "/reorgs", GetReorgs},
+	Route{"Wallet", "GET", "/wallet", GetWallet},
+	Route{"Wallet Address", "POST", "/wallet/address", PostWalletAddress},
+	Route{"Wallet Notifications", "GET", "/wallet/notifications", GetWalletNotifications},
 	// Route{"Upload", "POST", "/upload", Upload},
 	// Route{"Upload", "POST", "/request", PostRequest},
 	// Route{"Upload", "POST", "/search", PostSearch},
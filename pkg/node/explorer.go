@@ -0,0 +1,51 @@
+package node
+
+import (
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// GetBlocks returns a page of the canonical chain
+func (node *Node) GetBlocks(from, limit int) []*blockchain.BlockView {
+	return node.blockchain.GetBlocks(from, limit)
+}
+
+// GetBlock returns any block known by the node
+func (node *Node) GetBlock(hash string) (*blockchain.BlockView, bool) {
+	return node.blockchain.GetBlockView(hash)
+}
+
+// GetBlockAtHeight returns the canonical block at height
+func (node *Node) GetBlockAtHeight(height int) (*blockchain.BlockView, bool) {
+	return node.blockchain.GetBlockAtHeight(height)
+}
+
+// GetTransaction returns a confirmed or pending transaction
+func (node *Node) GetTransaction(hash string) (*blockchain.TransactionInfo, bool) {
+	return node.blockchain.GetTransactionInfo(hash)
+}
+
+// EstimateFee of a transaction mined within blocks
+func (node *Node) EstimateFee(blocks int) blockchain.FeeEstimate {
+	return node.blockchain.EstimateFee(blocks)
+}
+
+// GetAddressHistory returns the transactions touching the address
+func (node *Node) GetAddressHistory(address utils.HashValue) []blockchain.AddressEntry {
+	return node.blockchain.GetAddressHistory(address)
+}
+
+// GetPendingTransactions in the pool of the node
+func (node *Node) GetPendingTransactions() []blockchain.PendingTransaction {
+	return node.blockchain.GetPendingTransactions()
+}
+
+// GetForkBlocks known by the node outside of the canonical chain
+func (node *Node) GetForkBlocks() []blockchain.ForkBlock {
+	return node.blockchain.GetForkBlocks()
+}
+
+// GetReorgs of the canonical chain of the node
+func (node *Node) GetReorgs() []blockchain.Reorg {
+	return node.blockchain.GetReorgs()
+}
@@ -2,6 +2,7 @@ package node
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/ageapps/gambercoin/pkg/blockchain"
 	"github.com/ageapps/gambercoin/pkg/signal"
@@ -137,7 +138,19 @@ func (node *Node) handleStatusMessage(msg *monguer.StatusPacket, address string)
 	}
 }
 
+// isSameNetwork checks the genesis hash sent by a peer
+func (node *Node) isSameNetwork(genesis utils.HashValue, address string) bool {
+	if genesis != node.blockchain.GetGenesisHash() {
+		logger.Logv("Dropping message from %v of network %v", address, genesis.String())
+		return false
+	}
+	return true
+}
+
 func (node *Node) handleTxMessage(msg *blockchain.TxMessage, address string) {
+	if !node.isSameNetwork(msg.Genesis, address) {
+		return
+	}
 	node.blockchain.ReceiveChannel <- blockchain.ChainMessage{Tx: &msg.Tx, Origin: address}
 	msg.HopLimit--
 	if msg.HopLimit > 0 {
@@ -145,9 +158,80 @@ func (node *Node) handleTxMessage(msg *blockchain.TxMessage, address string) {
 	}
 }
 func (node *Node) handleBlockMessage(msg *blockchain.BlockMessage, address string) {
+	if !node.isSameNetwork(msg.Genesis, address) {
+		return
+	}
 	node.blockchain.ReceiveChannel <- blockchain.ChainMessage{Block: &msg.Block, Origin: address}
+	node.requestMissingParent(&msg.Block, address)
 	msg.HopLimit--
 	if msg.HopLimit > 0 {
 		node.publishBlock(msg.Block, msg.HopLimit, address)
 	}
 }
+
+func (node *Node) handleTipRequest(msg *blockchain.ChainTip, address string) {
+	if !node.isSameNetwork(msg.Genesis, address) {
+		return
+	}
+	node.sendTipReply(address)
+	node.syncWithPeer(msg, address)
+}
+
+func (node *Node) handleTipReply(msg *blockchain.ChainTip, address string) {
+	if !node.isSameNetwork(msg.Genesis, address) {
+		return
+	}
+	node.syncWithPeer(msg, address)
+}
+
+// syncWithPeer asks the peer for the blocks
+// after our canonical chain if its chain has more work
+func (node *Node) syncWithPeer(peerTip *blockchain.ChainTip, address string) {
+	if peerTip.Time > 0 {
+		node.blockchain.AddPeerTime(address, peerTip.Time)
+	}
+	_, height := node.blockchain.GetTip()
+	peerWork := new(big.Int).SetBytes(peerTip.Work)
+	if peerWork.Cmp(node.blockchain.GetTipWork()) > 0 && !node.blockchain.HasBlock(peerTip.Tip) {
+		logger.Logi("SYNC with %v at height %v, local height %v", address, peerTip.Height, height)
+		node.sendHashesRequest(address)
+	}
+}
+
+func (node *Node) handleHashesRequest(msg *blockchain.HashesRequest, address string) {
+	hashes := node.blockchain.GetHashesAfterLocator(msg.Locator, MAX_SYNC_HASHES)
+	node.sendHashesReply(address, hashes)
+}
+
+func (node *Node) handleHashesReply(msg *blockchain.HashesReply, address string) {
+	missing := []string{}
+	for _, hash := range msg.Hashes {
+		if !node.blockchain.HasBlock(hash) {
+			missing = append(missing, hash)
+		}
+	}
+	if len(missing) > 0 {
+		node.sendBlocksRequest(address, missing)
+	}
+}
+
+func (node *Node) handleBlocksRequest(msg *blockchain.BlocksRequest, address string) {
+	// a request is served as a reply of hashes, with the same limit
+	for _, block := range node.blockchain.GetRequestedBlocks(msg.Hashes, MAX_SYNC_HASHES) {
+		// blocks sent as reply are not relayed
+		node.sendBlockMessage(address, *block, 1)
+	}
+}
+
+// requestMissingParent asks the peer that sent
+// the block for its parent if it is not known
+func (node *Node) requestMissingParent(bl *blockchain.Block, address string) {
+	if bl.Header.PrevHash == [32]byte{} {
+		return
+	}
+	parent := bl.PrintPrev()
+	if !node.blockchain.HasBlock(parent) {
+		logger.Logi("Requesting missing parent %v to %v", parent, address)
+		node.sendBlocksRequest(address, []string{parent})
+	}
+}
@@ -1,6 +1,8 @@
 package node
 
 import (
+	"time"
+
 	"github.com/ageapps/gambercoin/pkg/blockchain"
 	"github.com/ageapps/gambercoin/pkg/data"
 	"github.com/ageapps/gambercoin/pkg/logger"
@@ -47,14 +49,55 @@ func (node *Node) sendPrivateMessage(msg *data.PrivateMessage) {
 	}
 }
 
-func (node *Node) publishTX(tx blockchain.Transaction, hops uint32, origin string) {
-	msg := blockchain.NewTxMessage(tx, hops)
+func (node *Node) publishTX(tx blockchain.TransactionMulti, hops uint32, origin string) {
+	msg := blockchain.NewTxMessage(tx, node.blockchain.GetGenesisHash(), hops)
 	packet := &data.GossipPacket{TxMessage: msg}
 	node.peerConection.BroadcastPacket(node.peers, packet, origin)
 }
 
 func (node *Node) publishBlock(bl blockchain.Block, hops uint32, origin string) {
-	msg := blockchain.NewBlockMessage(bl, hops)
+	msg := blockchain.NewBlockMessage(bl, node.blockchain.GetGenesisHash(), hops)
 	packet := &data.GossipPacket{BlockMessage: msg}
 	node.peerConection.BroadcastPacket(node.peers, packet, origin)
 }
+
+func (node *Node) sendTipRequest(destinationAdress string) {
+	packet := &data.GossipPacket{TipRequest: node.getChainTip()}
+	node.peerConection.SendPacketToPeer(destinationAdress, packet)
+}
+
+func (node *Node) sendTipReply(destinationAdress string) {
+	packet := &data.GossipPacket{TipReply: node.getChainTip()}
+	node.peerConection.SendPacketToPeer(destinationAdress, packet)
+}
+
+func (node *Node) getChainTip() *blockchain.ChainTip {
+	tip, height := node.blockchain.GetTip()
+	return &blockchain.ChainTip{
+		Tip:     tip,
+		Height:  height,
+		Work:    node.blockchain.GetTipWork().Bytes(),
+		Time:    time.Now().Unix(),
+		Genesis: node.blockchain.GetGenesisHash(),
+	}
+}
+
+func (node *Node) sendHashesRequest(destinationAdress string) {
+	msg := &blockchain.HashesRequest{Locator: node.blockchain.GetLocator()}
+	node.peerConection.SendPacketToPeer(destinationAdress, &data.GossipPacket{HashesRequest: msg})
+}
+
+func (node *Node) sendHashesReply(destinationAdress string, hashes []string) {
+	msg := &blockchain.HashesReply{Hashes: hashes}
+	node.peerConection.SendPacketToPeer(destinationAdress, &data.GossipPacket{HashesReply: msg})
+}
+
+func (node *Node) sendBlocksRequest(destinationAdress string, hashes []string) {
+	msg := &blockchain.BlocksRequest{Hashes: hashes}
+	node.peerConection.SendPacketToPeer(destinationAdress, &data.GossipPacket{BlocksRequest: msg})
+}
+
+func (node *Node) sendBlockMessage(destinationAdress string, bl blockchain.Block, hops uint32) {
+	msg := blockchain.NewBlockMessage(bl, node.blockchain.GetGenesisHash(), hops)
+	node.peerConection.SendPacketToPeer(destinationAdress, &data.GossipPacket{BlockMessage: msg})
+}
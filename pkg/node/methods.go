@@ -1,8 +1,10 @@
 package node
 
 import (
+	"crypto/rsa"
 	"log"
 
+	"github.com/ageapps/gambercoin/pkg/blockchain"
 	"github.com/ageapps/gambercoin/pkg/logger"
 
 	"github.com/ageapps/gambercoin/pkg/router"
@@ -112,3 +114,61 @@ func (node *Node) GetBalanceOfHash(hash utils.HashValue) int {
 	defer node.mux.Unlock()
 	return node.blockchain.GetBalanceOfHash(hash)
 }
+
+// Stats of the node
+type Stats struct {
+	Tip    string                 `json:"tip"`
+	Height int                    `json:"height"`
+	Peers  int                    `json:"peers"`
+	Mining blockchain.MiningStats `json:"mining"`
+}
+
+// GetStats of the chain and the miner of the node
+func (node *Node) GetStats() Stats {
+	tip, height := node.blockchain.GetTip()
+	return Stats{
+		Tip:    tip,
+		Height: height,
+		Peers:  len(node.GetPeers().GetAdresses()),
+		Mining: node.blockchain.GetMiningStats(),
+	}
+}
+
+// GetMiningStats of the node miner
+func (node *Node) GetMiningStats() blockchain.MiningStats {
+	return node.blockchain.GetMiningStats()
+}
+
+// SetMining turns the miner of the node on or off
+func (node *Node) SetMining(enabled bool) {
+	logger.Logi("Mining enabled: %v", enabled)
+	node.blockchain.SetMiningEnabled(enabled)
+}
+
+// SetMiningThreads of the proof of work engine
+func (node *Node) SetMiningThreads(threads int) error {
+	return node.blockchain.SetMiningThreads(threads)
+}
+
+// SetRewardAddress where the rewards of the next blocks are paid
+func (node *Node) SetRewardAddress(hash utils.HashValue) {
+	logger.Logi("Mining rewards paid to %v", hash.String())
+	node.blockchain.SetMinerHash(hash)
+}
+
+// SetMinerKey imports the key in the wallet as the default address,
+// the rewards are paid to it and client transactions spend from it
+func (node *Node) SetMinerKey(key *rsa.PrivateKey) error {
+	hash, err := node.wallet.ImportKey(key)
+	if err != nil {
+		return err
+	}
+	if err := node.wallet.SetDefaultAddress(hash); err != nil {
+		return err
+	}
+	node.mux.Lock()
+	node.MinerHash = hash
+	node.mux.Unlock()
+	node.SetRewardAddress(hash)
+	return nil
+}
@@ -3,6 +3,8 @@ package node
 import (
 	"fmt"
 	"log"
+	"os"
+	"path"
 	"sync"
 
 	"github.com/ageapps/gambercoin/pkg/blockchain"
@@ -15,6 +17,7 @@ import (
 	"github.com/ageapps/gambercoin/pkg/signal"
 	"github.com/ageapps/gambercoin/pkg/stack"
 	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/ageapps/gambercoin/pkg/wallet"
 )
 
 const (
@@ -25,6 +28,10 @@ const (
 	DEFAULT_MONGUER_TIMEOUT = 1
 	DEFAULT_BLOCK_HOPS      = 20
 	DEFAULT_TX_HOPS         = 10
+	// SYNC_TIMER_PERIOD in seconds
+	SYNC_TIMER_PERIOD = 5
+	// MAX_SYNC_HASHES sent in a hashes reply
+	MAX_SYNC_HASHES = 100
 )
 
 // Node struct
@@ -32,6 +39,7 @@ type Node struct {
 	Name            string
 	Address         utils.PeerAddress
 	MinerHash       utils.HashValue
+	wallet          *wallet.Wallet
 	peerConection   *connection.ConnectionHandler
 	peers           *utils.PeerAddresses
 	rumorStack      stack.MessageStack
@@ -45,21 +53,107 @@ type Node struct {
 	running         bool
 	receivedRoute   bool
 	blockchain      *blockchain.BlockChain
+	// sendMux keeps wallet transactions from selecting the same outputs
+	sendMux sync.Mutex
+}
+
+// Config struct
+// DataDir where the chain is stored, nothing is persisted if empty
+// Network preset name or path of a JSON file with the network params
+// Mempool limits of the transaction pool, defaults are used if empty
+// Consensus engine name, proof of work if empty
+// SealInterval in seconds between blocks of the interval engine
+// MiningThreads hashing in the proof of work engine
+// DisableMining to run a node that only validates and relays
+// RewardAddress hex hash the rewards are paid to, the wallet default address if empty
+// WalletPassword encrypting the keystore of the node, without it
+// the wallet is kept in memory even if DataDir is set
+// CoinSelection strategy and DustThreshold of the wallet, defaults if empty
+type Config struct {
+	DataDir        string
+	Network        string
+	Mempool        blockchain.MempoolConfig
+	Consensus      string
+	SealInterval   int64
+	MiningThreads  int
+	DisableMining  bool
+	RewardAddress  string
+	WalletPassword string
+	CoinSelection  string
+	DustThreshold  int
 }
 
 // NewNode return new instance
-func NewNode(addressStr, name string) (*Node, error) {
+// the chain and the wallet are stored in DataDir/networkId/name
+func NewNode(addressStr, name string, config Config) (*Node, error) {
 	address, err := utils.GetPeerAddress(addressStr)
 	if err != nil {
 		return nil, err
 	}
+	params, err := blockchain.LoadNetworkParams(config.Network)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := blockchain.NewConsensusEngine(config.Consensus, config.SealInterval, config.MiningThreads)
+	if err != nil {
+		return nil, err
+	}
+	var store blockchain.BlockStore = blockchain.NewMemoryStore()
+	nodeWallet := wallet.NewMemoryWallet()
+	if config.DataDir != "" {
+		dir := path.Join(config.DataDir, params.NetworkID, name)
+		fileStore, err := blockchain.NewFileStore(dir)
+		if err != nil {
+			return nil, err
+		}
+		store = fileStore
+		walletFile := path.Join(dir, wallet.WALLET_FILE)
+		if config.WalletPassword != "" {
+			if nodeWallet, err = wallet.OpenOrCreate(walletFile, config.WalletPassword); err != nil {
+				fileStore.Close()
+				return nil, err
+			}
+		} else if _, err := os.Stat(walletFile); err == nil {
+			fileStore.Close()
+			return nil, fmt.Errorf("keystore %v needs the wallet password", walletFile)
+		} else {
+			logger.Logw("No wallet password, the wallet of %v is kept in memory and its keys are lost on restart", name)
+		}
+	}
+
+	if err := nodeWallet.SetCoinSelection(config.CoinSelection, config.DustThreshold); err != nil {
+		return nil, err
+	}
 
 	logger.Logw("Listening to peers in address <%v>", addressStr)
-	minerHash := utils.CreateMinerHash()
+	minerHash, found := nodeWallet.GetDefaultAddress()
+	if !found {
+		if minerHash, err = nodeWallet.NewAddress(); err != nil {
+			return nil, err
+		}
+	}
+	rewardHash := minerHash
+	if config.RewardAddress != "" {
+		if rewardHash, err = utils.GetHash(config.RewardAddress); err != nil {
+			return nil, err
+		}
+	}
+	if config.Mempool == (blockchain.MempoolConfig{}) {
+		config.Mempool = blockchain.DefaultMempoolConfig()
+	}
+	chain, err := blockchain.NewBlockChain(name, rewardHash, store, params, config.Mempool, engine)
+	if err != nil {
+		return nil, err
+	}
+	chain.SetMiningEnabled(!config.DisableMining)
+	chain.WatchAddresses(nodeWallet.HasAddress)
+	genesis := chain.GetGenesisHash()
+	logger.Logi("Joined network %v with genesis %v sealing with %v", params.NetworkID, genesis.String(), engine.Name())
 	return &Node{
 		Name:            name,
 		Address:         address,
 		MinerHash:       minerHash,
+		wallet:          nodeWallet,
 		peers:           utils.EmptyAdresses(),
 		rumorStack:      stack.NewMessageStack(),
 		privateStack:    stack.NewMessageStack(),
@@ -70,7 +164,7 @@ func NewNode(addressStr, name string) (*Node, error) {
 		usedPeers:       make(map[string]bool),
 		running:         false,
 		receivedRoute:   false,
-		blockchain:      blockchain.NewBlockChain(name, minerHash),
+		blockchain:      chain,
 	}, nil
 }
 
@@ -83,9 +177,11 @@ func (node *Node) Start(clientChan <-chan client.Message) error {
 	}
 	node.peerConection = connection
 	node.setRunning(true)
+	node.startBlockchainProcess()
 	go node.listenToClientChannel(clientChan)
 	go node.startRouteTimer(DEFAULT_ROUTE_TIMEOUT)
 	go node.startEntropyTimer(ENTROPY_TIMER_PERIOD)
+	go node.startSyncTimer(SYNC_TIMER_PERIOD)
 	return node.listenToPeers()
 }
 
@@ -97,10 +193,10 @@ func (node *Node) startBlockchainProcess() {
 		for msg := range messageQueue {
 			packet := data.GossipPacket{}
 			if msg.IsTx() {
-				txMsg := blockchain.NewTxMessage(*msg.Tx, uint32(DEFAULT_TX_HOPS))
+				txMsg := blockchain.NewTxMessage(*msg.Tx, node.blockchain.GetGenesisHash(), uint32(DEFAULT_TX_HOPS))
 				packet.TxMessage = txMsg
 			} else if msg.IsBlock() {
-				blMsg := blockchain.NewBlockMessage(*msg.Block, uint32(DEFAULT_BLOCK_HOPS))
+				blMsg := blockchain.NewBlockMessage(*msg.Block, node.blockchain.GetGenesisHash(), uint32(DEFAULT_BLOCK_HOPS))
 				packet.BlockMessage = blMsg
 			}
 			if packet.TxMessage != nil || packet.BlockMessage != nil {
@@ -118,6 +214,7 @@ func (node *Node) Stop() {
 		process.SignalChannel <- signal.Stop
 		close(process.SignalChannel)
 	}
+	node.blockchain.Stop()
 	node.peerConection.Close()
 }
 
@@ -157,8 +254,7 @@ func (node *Node) handleClientMessage(msg *client.Message) {
 	switch {
 	case msg.IsTx():
 		logger.Logi("Message received is TRANSACTION")
-		tx := msg.Transaction.GetTransaction()
-		node.blockchain.ReceiveChannel <- blockchain.ChainMessage{Tx: &tx, Origin: node.Name}
+		node.handleClientTransaction(msg.Transaction)
 
 	case msg.Broadcast:
 		logger.LogClient((*msg).Text)
@@ -194,6 +290,12 @@ func (node *Node) handleClientDirectMessage(msg *client.Message) {
 	node.sendPrivateMessage(privateMessage)
 }
 
+func (node *Node) handleClientTransaction(clientTx *client.ClientTx) {
+	if _, err := node.SendTransaction(clientTx); err != nil {
+		logger.Logw("Error sending transaction: %v", err)
+	}
+}
+
 func (node *Node) handlePeerPacket(packet data.GossipPacket, originAddress string) {
 	if originAddress != node.Address.String() {
 		new, err := node.GetPeers().Add(originAddress)
@@ -202,6 +304,7 @@ func (node *Node) handlePeerPacket(packet data.GossipPacket, originAddress strin
 		}
 		if new {
 			logger.LogPeers(node.peers.String())
+			node.sendTipRequest(originAddress)
 		}
 	}
 
@@ -218,6 +321,16 @@ func (node *Node) handlePeerPacket(packet data.GossipPacket, originAddress strin
 		node.handleTxMessage(packet.TxMessage, originAddress)
 	case data.PACKET_BLOCK:
 		node.handleBlockMessage(packet.BlockMessage, originAddress)
+	case data.PACKET_TIP_REQUEST:
+		node.handleTipRequest(packet.TipRequest, originAddress)
+	case data.PACKET_TIP_REPLY:
+		node.handleTipReply(packet.TipReply, originAddress)
+	case data.PACKET_HASHES_REQUEST:
+		node.handleHashesRequest(packet.HashesRequest, originAddress)
+	case data.PACKET_HASHES_REPLY:
+		node.handleHashesReply(packet.HashesReply, originAddress)
+	case data.PACKET_BLOCKS_REQUEST:
+		node.handleBlocksRequest(packet.BlocksRequest, originAddress)
 	case data.PACKET_SIMPLE:
 		msg := *packet.Simple
 		logger.LogSimple(msg.OriginalName, msg.RelayPeerAddr, msg.Contents)
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/client"
+	"github.com/ageapps/gambercoin/pkg/logger"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// SendTransaction creates a transaction with the outputs of the
+// wallet, adds it to the pool and relays it, its name is returned
+func (node *Node) SendTransaction(clientTx *client.ClientTx) (string, error) {
+	from := []utils.HashValue{}
+	if clientTx.In != "" {
+		in, err := utils.GetHash(clientTx.In)
+		if err != nil {
+			return "", fmt.Errorf("transaction input %v not valid", clientTx.In)
+		}
+		from = append(from, in)
+	}
+	out, err := utils.GetHash(clientTx.Out)
+	if err != nil {
+		return "", fmt.Errorf("transaction output %v not valid", clientTx.Out)
+	}
+	fee := clientTx.Fee
+	if fee < 0 {
+		fee = node.blockchain.EstimateFee(blockchain.DEFAULT_FEE_TARGET).Fee
+		logger.Logi("Using estimated fee %v", fee)
+	}
+	// outputs are spendable until the previous transaction is pooled
+	node.sendMux.Lock()
+	defer node.sendMux.Unlock()
+	tx, err := node.wallet.CreateTransaction(node.blockchain, from, out, clientTx.Amount, fee, clientTx.LockTime)
+	if err != nil {
+		return "", err
+	}
+	if err := node.SendRawTransaction(tx); err != nil {
+		return "", err
+	}
+	return tx.String(), nil
+}
+
+// CreateRawTransaction builds an unsigned transaction spending the inputs
+func (node *Node) CreateRawTransaction(inputs []blockchain.Input, outputs []blockchain.Output, lockTime int64) (*blockchain.UnsignedTransaction, error) {
+	return node.blockchain.CreateRawTransaction(inputs, outputs, lockTime)
+}
+
+// SendRawTransaction adds a transaction signed outside of
+// the node to the pool and relays it to the peers
+func (node *Node) SendRawTransaction(tx *blockchain.TransactionMulti) error {
+	if err := node.blockchain.SubmitTransaction(tx, node.Name); err != nil {
+		return err
+	}
+	logger.Logi("Relaying raw transaction %v", tx.String())
+	node.publishTX(*tx, uint32(DEFAULT_TX_HOPS), "")
+	return nil
+}
@@ -72,3 +72,19 @@ func (node *Node) sendRandomRouteMessage(usedPeers map[string]bool) string {
 	}
 	return ""
 }
+
+// startSyncTimer function
+// this timer asks a random peer for its chain tip
+// and for the parents of the orphan blocks
+func (node *Node) startSyncTimer(stimer int) {
+	for node.IsRunning() {
+		if peer := node.GetPeers().GetRandomPeer(make(map[string]bool)); peer != nil {
+			logger.Logv("Sync Timer - MESSAGE to %v", peer.String())
+			node.sendTipRequest(peer.String())
+			if missing := node.blockchain.GetMissingParents(); len(missing) > 0 {
+				node.sendBlocksRequest(peer.String(), missing)
+			}
+		}
+		time.Sleep(time.Duration(stimer) * time.Second)
+	}
+}
@@ -0,0 +1,76 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/logger"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// WalletAddress of the node wallet with its confirmed balance
+type WalletAddress struct {
+	Address string `json:"address"`
+	Balance int    `json:"balance"`
+}
+
+// WalletInfo of the node wallet
+type WalletInfo struct {
+	Default   string          `json:"default"`
+	Balance   int             `json:"balance"`
+	Addresses []WalletAddress `json:"addresses"`
+}
+
+// GetWallet returns the addresses of the wallet and their balances
+func (node *Node) GetWallet() WalletInfo {
+	info := WalletInfo{Addresses: []WalletAddress{}}
+	if address, found := node.wallet.GetDefaultAddress(); found {
+		info.Default = address.String()
+	}
+	for _, address := range node.wallet.GetAddresses() {
+		balance := node.blockchain.GetBalanceOfHash(address)
+		info.Balance += balance
+		info.Addresses = append(info.Addresses, WalletAddress{Address: address.String(), Balance: balance})
+	}
+	return info
+}
+
+// NewWalletAddress generates a new key in the wallet
+func (node *Node) NewWalletAddress() (utils.HashValue, error) {
+	address, err := node.wallet.NewAddress()
+	if err != nil {
+		return address, err
+	}
+	logger.Logi("New wallet address %v", address.String())
+	return address, nil
+}
+
+// GetTransactionStatus of a transaction seen by the node
+func (node *Node) GetTransactionStatus(hash string) blockchain.TransactionStatus {
+	return node.blockchain.GetTransactionStatus(hash)
+}
+
+// GetWalletNotifications of the address, all the wallet addresses
+// if empty, with an id greater than since
+func (node *Node) GetWalletNotifications(address string, since uint64) ([]blockchain.Notification, error) {
+	addresses := node.wallet.GetAddresses()
+	if address != "" {
+		hash, err := utils.GetHash(address)
+		if err != nil {
+			return nil, err
+		}
+		if !node.wallet.HasAddress(hash) {
+			return nil, fmt.Errorf("address %v not in wallet", address)
+		}
+		addresses = []utils.HashValue{hash}
+	}
+	notifications := []blockchain.Notification{}
+	for _, hash := range addresses {
+		notifications = append(notifications, node.blockchain.GetNotifications(hash, since)...)
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].ID < notifications[j].ID
+	})
+	return notifications, nil
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 )
 
 // HashValue is a file containing the SHA-256 hashes of each chunk
@@ -17,10 +18,16 @@ func (hash *HashValue) String() string {
 // Set HashValue from string
 func (hash *HashValue) Set(value string) error {
 	newHash, err := hex.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	if len(newHash) != len(hash) {
+		return fmt.Errorf("hash %v should have %v bytes", value, len(hash))
+	}
 	for i := 0; i < 32; i++ {
 		(*hash)[i] = newHash[i]
 	}
-	return err
+	return nil
 }
 
 // Equals from string
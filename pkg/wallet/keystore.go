@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// KEYSTORE_VERSION of the keystore file format
+	KEYSTORE_VERSION = 1
+	// KDF_ITERATIONS of PBKDF2 used to derive the encryption key
+	KDF_ITERATIONS = 100000
+
+	saltSize = 16
+	keySize  = 32
+)
+
+// keystoreFile is the encrypted content written to disk,
+// the keys are encrypted with AES-GCM and a key derived
+// from the password with PBKDF2-SHA256
+type keystoreFile struct {
+	Version    int         `json:"version"`
+	Iterations int         `json:"iterations"`
+	Salt       utils.Bytes `json:"salt"`
+	Nonce      utils.Bytes `json:"nonce"`
+	Ciphertext utils.Bytes `json:"ciphertext"`
+}
+
+// storedKey of the decrypted keystore
+type storedKey struct {
+	Address string      `json:"address"`
+	Key     utils.Bytes `json:"key"`
+}
+
+// writeKeystore encrypts the keys and replaces the file
+func writeKeystore(file, password string, keys []storedKey) error {
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := newCipher(password, salt, KDF_ITERATIONS)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	content, err := json.Marshal(keystoreFile{
+		Version:    KEYSTORE_VERSION,
+		Iterations: KDF_ITERATIONS,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(file), 0700); err != nil {
+		return err
+	}
+	// never leave a half written keystore
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// readKeystore decrypts the keys of the file
+func readKeystore(file, password string) ([]storedKey, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	stored := keystoreFile{}
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, fmt.Errorf("keystore %v not valid: %v", file, err)
+	}
+	if stored.Version != KEYSTORE_VERSION {
+		return nil, fmt.Errorf("keystore version %v not supported", stored.Version)
+	}
+	aead, err := newCipher(password, stored.Salt, stored.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored.Nonce) != aead.NonceSize() {
+		return nil, errors.New("keystore nonce not valid")
+	}
+	plaintext, err := aead.Open(nil, stored.Nonce, stored.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("wrong password or corrupted keystore")
+	}
+	keys := []storedKey{}
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func newCipher(password string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 {
+		return nil, errors.New("keystore iterations not valid")
+	}
+	block, err := aes.NewCipher(deriveKey([]byte(password), salt, iterations, keySize))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey with PBKDF2 using HMAC-SHA256 as defined in RFC 8018
+func deriveKey(password, salt []byte, iterations, size int) []byte {
+	prf := hmac.New(sha256.New, password)
+	key := []byte{}
+	for block := uint32(1); len(key) < size; block++ {
+		counter := make([]byte, 4)
+		binary.BigEndian.PutUint32(counter, block)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter)
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:size]
+}
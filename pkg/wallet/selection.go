@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+)
+
+const (
+	// SELECTION_LARGEST_FIRST spends the biggest outputs until the target is reached
+	SELECTION_LARGEST_FIRST = "largest"
+	// SELECTION_BRANCH_AND_BOUND looks for outputs matching the target
+	// without change, it falls back to largest first
+	SELECTION_BRANCH_AND_BOUND = "bnb"
+	// DEFAULT_DUST_THRESHOLD change below it is left to the miner
+	DEFAULT_DUST_THRESHOLD = 3
+	// MAX_SELECTION_TRIES of the branch and bound search
+	MAX_SELECTION_TRIES = 100000
+)
+
+// Selection of outputs to spend
+// Change coins sent back to the wallet, 0 if no change output is needed
+// Fee paid to the miner, it includes the change under the dust threshold
+type Selection struct {
+	Outputs []blockchain.UnspentOutput
+	Value   int
+	Change  int
+	Fee     int
+}
+
+// SelectCoins picks outputs holding at least the amount plus the fee
+func SelectCoins(unspent []blockchain.UnspentOutput, amount, fee, dust int, strategy string) (*Selection, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount has to be positive")
+	}
+	if fee < 0 {
+		return nil, errors.New("fee can not be negative")
+	}
+	if amount > blockchain.MAX_MONEY || fee > blockchain.MAX_MONEY-amount {
+		return nil, fmt.Errorf("amount plus fee over the max of %v", blockchain.MAX_MONEY)
+	}
+	if dust < 1 {
+		dust = 1
+	}
+	sorted := append([]blockchain.UnspentOutput{}, unspent...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Output.Value > sorted[j].Output.Value
+	})
+	var outputs []blockchain.UnspentOutput
+	switch strategy {
+	case SELECTION_BRANCH_AND_BOUND:
+		outputs = branchAndBound(sorted, amount+fee, dust)
+		if outputs == nil {
+			outputs = largestFirst(sorted, amount+fee)
+		}
+	case SELECTION_LARGEST_FIRST:
+		outputs = largestFirst(sorted, amount+fee)
+	default:
+		return nil, fmt.Errorf("coin selection %v not supported", strategy)
+	}
+	if outputs == nil {
+		available := 0
+		for _, output := range sorted {
+			available += output.Output.Value
+		}
+		return nil, fmt.Errorf("not enough funds, %v available", available)
+	}
+	selection := &Selection{Outputs: outputs, Fee: fee}
+	for _, output := range outputs {
+		selection.Value += output.Output.Value
+	}
+	selection.Change = selection.Value - amount - fee
+	if selection.Change < dust {
+		selection.Fee += selection.Change
+		selection.Change = 0
+	}
+	return selection, nil
+}
+
+// largestFirst takes the sorted outputs until the target is reached
+func largestFirst(sorted []blockchain.UnspentOutput, target int) []blockchain.UnspentOutput {
+	outputs := []blockchain.UnspentOutput{}
+	value := 0
+	for _, output := range sorted {
+		if value >= target {
+			break
+		}
+		outputs = append(outputs, output)
+		value += output.Output.Value
+	}
+	if value < target {
+		return nil
+	}
+	return outputs
+}
+
+// branchAndBound searches the sorted outputs for a set whose value
+// is within dust of the target, so that no change output is needed
+func branchAndBound(sorted []blockchain.UnspentOutput, target, dust int) []blockchain.UnspentOutput {
+	// remaining[i] value of the outputs from i to the end
+	remaining := make([]int, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + sorted[i].Output.Value
+	}
+	tries := 0
+	selected := []int{}
+	var search func(index, value int) bool
+	search = func(index, value int) bool {
+		tries++
+		if value >= target {
+			return value < target+dust
+		}
+		if index == len(sorted) || value+remaining[index] < target || tries > MAX_SELECTION_TRIES {
+			return false
+		}
+		selected = append(selected, index)
+		if search(index+1, value+sorted[index].Output.Value) {
+			return true
+		}
+		selected = selected[:len(selected)-1]
+		return search(index+1, value)
+	}
+	if !search(0, 0) {
+		return nil
+	}
+	outputs := []blockchain.UnspentOutput{}
+	for _, index := range selected {
+		outputs = append(outputs, sorted[index])
+	}
+	return outputs
+}
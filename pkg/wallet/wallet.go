@@ -0,0 +1,328 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+const (
+	// KEY_SIZE of the wallet keys in bits
+	KEY_SIZE = 2048
+	// WALLET_FILE name of the keystore inside the data directory of a node
+	WALLET_FILE = "wallet.json"
+)
+
+// UnspentSource returns the confirmed outputs owned by an address,
+// it is implemented by the BlockChain
+type UnspentSource interface {
+	GetUnspentOutputs(hash utils.HashValue) []blockchain.UnspentOutput
+}
+
+// SpendableSource returns the outputs an address can spend in
+// a new transaction, the confirmed ones not spent in the pool
+type SpendableSource interface {
+	UnspentSource
+	GetSpendableOutputs(hash utils.HashValue) []blockchain.UnspentOutput
+}
+
+// Wallet struct
+// Keys of the wallet by hex address, addresses in the
+// order they were added, the first one is the default.
+// The keystore is rewritten on every change if the wallet has a file
+type Wallet struct {
+	file      string
+	password  string
+	keySize   int
+	strategy  string
+	dust      int
+	keys      map[string]*rsa.PrivateKey
+	addresses []utils.HashValue
+	mux       sync.Mutex
+}
+
+// NewMemoryWallet creates a wallet that is not stored
+func NewMemoryWallet() *Wallet {
+	return &Wallet{
+		keySize:   KEY_SIZE,
+		strategy:  SELECTION_BRANCH_AND_BOUND,
+		dust:      DEFAULT_DUST_THRESHOLD,
+		keys:      make(map[string]*rsa.PrivateKey),
+		addresses: []utils.HashValue{},
+	}
+}
+
+// Create an empty wallet stored in file, it fails if the file
+// exists or the password is empty, keys are never stored in the clear
+func Create(file, password string) (*Wallet, error) {
+	if password == "" {
+		return nil, errors.New("keystore password is empty")
+	}
+	if _, err := os.Stat(file); err == nil {
+		return nil, fmt.Errorf("keystore %v already exists", file)
+	}
+	wallet := NewMemoryWallet()
+	wallet.file = file
+	wallet.password = password
+	if err := wallet.save(); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// Open the wallet stored in file with the password
+func Open(file, password string) (*Wallet, error) {
+	keys, err := readKeystore(file, password)
+	if err != nil {
+		return nil, err
+	}
+	wallet := NewMemoryWallet()
+	wallet.file = file
+	wallet.password = password
+	for _, stored := range keys {
+		key, err := blockchain.ParsePrivateKey(stored.Key)
+		if err != nil {
+			return nil, fmt.Errorf("key of %v not valid: %v", stored.Address, err)
+		}
+		wallet.add(key)
+	}
+	return wallet, nil
+}
+
+// OpenOrCreate opens the wallet in file or creates it if it does not exist
+func OpenOrCreate(file, password string) (*Wallet, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return Create(file, password)
+	}
+	return Open(file, password)
+}
+
+// SetKeySize of the new keys, used by tests to create keys faster
+func (wallet *Wallet) SetKeySize(bits int) {
+	wallet.mux.Lock()
+	wallet.keySize = bits
+	wallet.mux.Unlock()
+}
+
+// SetCoinSelection strategy and dust threshold of the new
+// transactions, defaults are used if they are empty
+func (wallet *Wallet) SetCoinSelection(strategy string, dust int) error {
+	switch strategy {
+	case "":
+		strategy = SELECTION_BRANCH_AND_BOUND
+	case SELECTION_BRANCH_AND_BOUND, SELECTION_LARGEST_FIRST:
+	default:
+		return fmt.Errorf("coin selection %v not supported", strategy)
+	}
+	if dust <= 0 {
+		dust = DEFAULT_DUST_THRESHOLD
+	}
+	wallet.mux.Lock()
+	wallet.strategy = strategy
+	wallet.dust = dust
+	wallet.mux.Unlock()
+	return nil
+}
+
+// NewAddress generates a new key and returns its address
+func (wallet *Wallet) NewAddress() (utils.HashValue, error) {
+	wallet.mux.Lock()
+	bits := wallet.keySize
+	wallet.mux.Unlock()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return utils.HashValue{}, err
+	}
+	return wallet.ImportKey(key)
+}
+
+// ImportKey adds an existing key to the wallet and returns its address
+func (wallet *Wallet) ImportKey(key *rsa.PrivateKey) (utils.HashValue, error) {
+	wallet.mux.Lock()
+	defer wallet.mux.Unlock()
+	address := blockchain.GetPubKeyHash(&key.PublicKey)
+	if _, found := wallet.keys[address.String()]; found {
+		return address, nil
+	}
+	wallet.add(key)
+	if err := wallet.save(); err != nil {
+		wallet.remove(address)
+		return utils.HashValue{}, err
+	}
+	return address, nil
+}
+
+// GetAddresses of the wallet, the default one first
+func (wallet *Wallet) GetAddresses() []utils.HashValue {
+	wallet.mux.Lock()
+	defer wallet.mux.Unlock()
+	return append([]utils.HashValue{}, wallet.addresses...)
+}
+
+// GetDefaultAddress returns the first address of the wallet
+func (wallet *Wallet) GetDefaultAddress() (utils.HashValue, bool) {
+	wallet.mux.Lock()
+	defer wallet.mux.Unlock()
+	if len(wallet.addresses) == 0 {
+		return utils.HashValue{}, false
+	}
+	return wallet.addresses[0], true
+}
+
+// SetDefaultAddress moves an address of the wallet to the first position
+func (wallet *Wallet) SetDefaultAddress(address utils.HashValue) error {
+	wallet.mux.Lock()
+	defer wallet.mux.Unlock()
+	if _, found := wallet.keys[address.String()]; !found {
+		return fmt.Errorf("address %v not in wallet", address.String())
+	}
+	addresses := []utils.HashValue{address}
+	for _, owned := range wallet.addresses {
+		if owned != address {
+			addresses = append(addresses, owned)
+		}
+	}
+	wallet.addresses = addresses
+	return wallet.save()
+}
+
+// HasAddress check
+func (wallet *Wallet) HasAddress(address utils.HashValue) bool {
+	_, found := wallet.GetKey(address)
+	return found
+}
+
+// GetKey of an address of the wallet
+func (wallet *Wallet) GetKey(address utils.HashValue) (*rsa.PrivateKey, bool) {
+	wallet.mux.Lock()
+	defer wallet.mux.Unlock()
+	key, found := wallet.keys[address.String()]
+	return key, found
+}
+
+// GetUnspentOutputs owned by the addresses of the wallet
+func (wallet *Wallet) GetUnspentOutputs(source UnspentSource) []blockchain.UnspentOutput {
+	unspent := []blockchain.UnspentOutput{}
+	for _, address := range wallet.GetAddresses() {
+		unspent = append(unspent, source.GetUnspentOutputs(address)...)
+	}
+	return unspent
+}
+
+// GetBalance of all the addresses of the wallet
+func (wallet *Wallet) GetBalance(source UnspentSource) int {
+	balance := 0
+	for _, unspent := range wallet.GetUnspentOutputs(source) {
+		balance += unspent.Output.Value
+	}
+	return balance
+}
+
+// CreateTransaction pays amount to the receiver with outputs of the
+// from addresses, all the wallet addresses if empty. The change is
+// sent to a new address of the wallet unless it is under the dust threshold,
+// a lockTime other than 0 keeps the transaction out of blocks until it matures.
+// Amounts under the dust threshold are rejected
+func (wallet *Wallet) CreateTransaction(source SpendableSource, from []utils.HashValue, receiver utils.HashValue, amount, fee int, lockTime int64) (*blockchain.TransactionMulti, error) {
+	wallet.mux.Lock()
+	strategy, dust := wallet.strategy, wallet.dust
+	wallet.mux.Unlock()
+	if amount < dust {
+		return nil, fmt.Errorf("amount %v under the dust threshold %v", amount, dust)
+	}
+	if len(from) == 0 {
+		from = wallet.GetAddresses()
+	}
+	unspent := []blockchain.UnspentOutput{}
+	// a repeated address would spend its outputs twice
+	seen := make(map[utils.HashValue]bool)
+	for _, address := range from {
+		if !wallet.HasAddress(address) {
+			return nil, fmt.Errorf("address %v not in wallet", address.String())
+		}
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		unspent = append(unspent, source.GetSpendableOutputs(address)...)
+	}
+	selection, err := SelectCoins(unspent, amount, fee, dust, strategy)
+	if err != nil {
+		return nil, err
+	}
+	inputs := []blockchain.Input{}
+	for _, output := range selection.Outputs {
+		inputs = append(inputs, blockchain.Input{PrevOut: output.PrevOut, Index: output.Index})
+	}
+	outputs := []blockchain.Output{blockchain.Output{PubKeyHash: receiver, Value: amount}}
+	if selection.Change > 0 {
+		change, err := wallet.NewAddress()
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, blockchain.Output{PubKeyHash: change, Value: selection.Change})
+	}
+	tx := blockchain.NewTransactionMulti(inputs, outputs, selection.Fee)
+	if lockTime != 0 {
+		tx.SetLockTime(lockTime)
+	}
+	if err := wallet.Sign(&tx, source); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// Sign every input of the transaction with the key
+// of the wallet address that owns the spent output
+func (wallet *Wallet) Sign(tx *blockchain.TransactionMulti, source UnspentSource) error {
+	owners := make(map[string]utils.HashValue)
+	for _, unspent := range wallet.GetUnspentOutputs(source) {
+		owners[fmt.Sprintf("%v:%v", unspent.PrevOut.String(), unspent.Index)] = unspent.Output.PubKeyHash
+	}
+	for index, input := range tx.Inputs {
+		owner, found := owners[fmt.Sprintf("%v:%v", input.PrevOut.String(), input.Index)]
+		if !found {
+			return fmt.Errorf("input %v does not spend an output of the wallet", index)
+		}
+		key, _ := wallet.GetKey(owner)
+		if err := tx.SignInput(index, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wallet *Wallet) add(key *rsa.PrivateKey) {
+	address := blockchain.GetPubKeyHash(&key.PublicKey)
+	wallet.keys[address.String()] = key
+	wallet.addresses = append(wallet.addresses, address)
+}
+
+func (wallet *Wallet) remove(address utils.HashValue) {
+	delete(wallet.keys, address.String())
+	for index, owned := range wallet.addresses {
+		if owned == address {
+			wallet.addresses = append(wallet.addresses[:index], wallet.addresses[index+1:]...)
+			break
+		}
+	}
+}
+
+// save the keystore, memory wallets are not saved
+func (wallet *Wallet) save() error {
+	if wallet.file == "" {
+		return nil
+	}
+	keys := []storedKey{}
+	for _, address := range wallet.addresses {
+		key := wallet.keys[address.String()]
+		keys = append(keys, storedKey{Address: address.String(), Key: blockchain.MarshalPrivateKey(key)})
+	}
+	return writeKeystore(wallet.file, wallet.password, keys)
+}
@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+)
+
+func TestNetworkClock(t *testing.T) {
+	t.Log("Testing network time adjusted with the peers")
+
+	clock := blockchain.NewNetworkClock()
+	now := time.Now().Unix()
+	clock.AddSample("peerA", now+200)
+	if clock.Offset() != 0 {
+		t.Errorf("A single peer should not move the clock %v", clock.Offset())
+	}
+	for index := 1; index < blockchain.MinTimeSamples; index++ {
+		clock.AddSample(fmt.Sprintf("peer%v", index), now+100)
+	}
+	if offset := clock.Offset(); offset < 99 || offset > 101 {
+		t.Errorf("Median offset of the peers not used %v", offset)
+	}
+	for index := 1; index < blockchain.MinTimeSamples; index++ {
+		clock.AddSample(fmt.Sprintf("peer%v", index), now+2*blockchain.MaxTimeOffset)
+	}
+	if clock.Offset() != 0 {
+		t.Errorf("Offsets over the maximum should be ignored %v", clock.Offset())
+	}
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestConsensusEngines(t *testing.T) {
+	t.Log("Testing consensus engine selection and seals")
+
+	if _, err := blockchain.NewConsensusEngine("unknown", 0, 1); err == nil {
+		t.Error("Unknown engine should be rejected")
+	}
+	engine, err := blockchain.NewConsensusEngine("", 0, 1)
+	if err != nil || engine.Name() != blockchain.CONSENSUS_POW {
+		t.Errorf("Proof of work should be the default engine, %v", err)
+	}
+
+	params, _ := blockchain.LoadNetworkParams("test")
+	genesis, _ := params.GenesisBlock()
+	chain := blockchain.Chain{Blocks: []*blockchain.Block{genesis}}
+	never := func() bool { return false }
+
+	instant, _ := blockchain.NewConsensusEngine(blockchain.CONSENSUS_INSTANT, 0, 1)
+	block := blockchain.NewBlock(genesis.Hash())
+	block.Header.Target = instant.GetTarget(&chain)
+	block.Header.Timestamp = genesis.Header.Timestamp + 1
+	if !instant.Seal(&chain, block, never) || instant.VerifySeal(block) != nil || instant.VerifyBlock(&chain, block) != nil {
+		t.Error("Instant seal should accept the block without work")
+	}
+	if (&blockchain.ProofOfWork{}).VerifySeal(block) == nil && !blockchain.MeetsTarget(block.Hash(), block.Header.Target) {
+		t.Error("Proof of work should reject the block without work")
+	}
+
+	interval := &blockchain.FixedInterval{Interval: 5}
+	if interval.VerifyBlock(&chain, block) == nil {
+		t.Error("Block before the interval should be rejected")
+	}
+	abort := func() bool { return true }
+	if interval.Seal(&chain, block, abort) {
+		t.Error("Aborted seal should fail")
+	}
+	if block.Header.Timestamp != genesis.Header.Timestamp+5 || interval.VerifyBlock(&chain, block) != nil {
+		t.Errorf("Seal should move the timestamp to the interval %v", block.Header.Timestamp)
+	}
+
+	miner := utils.MakeHashString("miner")
+	for _, network := range []string{"main", "staging"} {
+		params, _ := blockchain.LoadNetworkParams(network)
+		for _, engine := range []blockchain.ConsensusEngine{instant, interval} {
+			if _, err := blockchain.NewBlockChain("test", miner, blockchain.NewMemoryStore(), params, blockchain.DefaultMempoolConfig(), engine); err == nil {
+				t.Errorf("Engine %v without work should be refused in the %v network", engine.Name(), network)
+			}
+		}
+	}
+}
+
+func TestInstantSealChain(t *testing.T) {
+	t.Log("Testing chain sealing blocks instantly")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, params, _ := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.InstantSeal{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	sent := bc.Start(func() {})
+	defer bc.Stop()
+	go func() {
+		for range sent {
+		}
+	}()
+
+	tx, err := bc.CreateTransaction(key, miner, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc.ReceiveChannel <- blockchain.ChainMessage{Tx: tx, Origin: "peer"}
+
+	if height := waitForHeight(bc, 2); height != 2 {
+		t.Fatalf("Block not sealed, height %v", height)
+	}
+	if balance := bc.GetBalanceOfHash(miner); balance != params.GetBlockReward(1)+12 {
+		t.Errorf("Miner balance not matching %v", balance)
+	}
+}
+
+func TestProofOfWorkWorkers(t *testing.T) {
+	t.Log("Testing proof of work sealing with several workers")
+
+	miner := utils.MakeHashString("miner")
+	params, _ := blockchain.LoadNetworkParams("test")
+	genesis, _ := params.GenesisBlock()
+	chain := blockchain.Chain{Blocks: []*blockchain.Block{genesis}}
+	never := func() bool { return false }
+
+	engine := blockchain.NewProofOfWork(4)
+	block := blockchain.NewBlock(genesis.Hash())
+	block.Header.Target = engine.GetTarget(&chain)
+	block.Header.Timestamp = genesis.Header.Timestamp + 1
+	coinbase := blockchain.NewCoinbase(miner, 1, 1)
+	coinbase.SetExtraNonce(0)
+	block.AppendTransaction(coinbase)
+	if !engine.Seal(&chain, block, never) {
+		t.Fatal("Block not sealed")
+	}
+	if err := engine.VerifySeal(block); err != nil {
+		t.Error(err)
+	}
+	if block.Header.MerkleRoot != block.ComputeMerkleRoot() {
+		t.Error("Merkle root not matching the sealed coinbase")
+	}
+	if engine.GetHashes() == 0 {
+		t.Error("Hashes not counted")
+	}
+
+	// no hash meets a zero target, the workers only stop on abort
+	impossible := *block
+	impossible.Header.Target = utils.HashValue{}
+	deadline := time.Now().Add(50 * time.Millisecond)
+	abort := func() bool { return time.Now().After(deadline) }
+	if engine.Seal(&chain, &impossible, abort) {
+		t.Error("Block with zero target should not be sealed")
+	}
+	if time.Now().After(deadline.Add(time.Second)) {
+		t.Error("Workers not stopped on abort")
+	}
+}
+
+// waitForHeight of the canonical chain for a couple of seconds
+func waitForHeight(bc *blockchain.BlockChain, expected int) int {
+	deadline := time.Now().Add(2 * time.Second)
+	_, height := bc.GetTip()
+	for height < expected && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		_, height = bc.GetTip()
+	}
+	return height
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestDifficultyRetarget(t *testing.T) {
+	t.Log("Testing difficulty retargeting")
+
+	expected := int64((blockchain.RetargetInterval - 1) * blockchain.TargetBlockTime)
+	target := blockchain.PowLimit
+	target[2] = 0x00
+
+	same := blockchain.CalculateNextTarget(target, 0, expected)
+	if same != target {
+		t.Errorf("Target should not change %v", same.String())
+	}
+
+	harder := blockchain.CalculateNextTarget(target, 0, expected/2)
+	if !blockchain.MeetsTarget(harder, target) || harder == target {
+		t.Errorf("Target should be lower %v", harder.String())
+	}
+
+	bounded := blockchain.CalculateNextTarget(target, 0, 0)
+	fastest := blockchain.CalculateNextTarget(target, 0, expected/blockchain.MaxRetargetFactor)
+	if bounded != fastest {
+		t.Errorf("Retarget should be bounded %v %v", bounded.String(), fastest.String())
+	}
+
+	easiest := blockchain.CalculateNextTarget(blockchain.PowLimit, 0, expected*10)
+	if easiest != blockchain.PowLimit {
+		t.Errorf("Target should not exceed the limit %v", easiest.String())
+	}
+
+	var hash utils.HashValue
+	if !blockchain.MeetsTarget(hash, target) {
+		t.Error("Zero hash should meet any target")
+	}
+	hash[0] = 0x01
+	if blockchain.MeetsTarget(hash, blockchain.PowLimit) {
+		t.Errorf("Hash %v should not meet the limit", hash.String())
+	}
+}
+
+func TestBlockWork(t *testing.T) {
+	t.Log("Testing block work")
+
+	easy := blockchain.GetBlockWork(blockchain.PowLimit)
+	if easy.Int64() != 1<<16 {
+		t.Errorf("Work of the easiest target %v", easy)
+	}
+	target := blockchain.PowLimit
+	target[2] = 0x00
+	if harder := blockchain.GetBlockWork(target); harder.Cmp(easy) <= 0 {
+		t.Errorf("Harder target should need more work %v", harder)
+	}
+}
+
+func TestForkChoice(t *testing.T) {
+	t.Log("Testing fork choice by cumulative work")
+
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{})
+	bc.Start(func() {})
+	defer bc.Stop()
+	process := func(blocks ...*blockchain.Block) {
+		for _, block := range blocks {
+			bc.ReceiveChannel <- blockchain.ChainMessage{Block: block, Origin: "peer"}
+		}
+		// the previous message is processed once this one is received
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+	}
+
+	reward := params.GetBlockReward(1)
+	blockA := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward, 1))
+	blockB := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward-1, 1))
+	process(blockA, blockB)
+	winner := blockA
+	if blockB.String() < blockA.String() {
+		winner = blockB
+	}
+	if tip, _ := bc.GetTip(); tip != winner.String() {
+		t.Errorf("Equal work should be won by the lowest hash, tip %v", tip)
+	}
+
+	loser := blockA
+	if winner == blockA {
+		loser = blockB
+	}
+	next := mineTestBlock(loser.Hash(), blockchain.NewCoinbase(miner, reward, 2))
+	process(next)
+	if tip, height := bc.GetTip(); tip != next.String() || height != 3 {
+		t.Errorf("Chain with more work should win, tip %v at height %v", tip, height)
+	}
+	expected := loser.Body.Transactions[0].GetOutputValue() + reward
+	if balance := bc.GetBalanceOfHash(miner); balance != expected {
+		t.Errorf("Balance not updated after reorg %v expected %v", balance, expected)
+	}
+}
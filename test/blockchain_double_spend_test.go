@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestDoubleSpend(t *testing.T) {
+	t.Log("Testing double spends against the pool and within blocks")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+	defer bc.Stop()
+	process := func(messages ...blockchain.ChainMessage) {
+		for _, message := range messages {
+			bc.ReceiveChannel <- message
+		}
+		// the previous message is processed once this one is received
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+	}
+	unspent := bc.GetUnspentOutputs(owner)[0]
+	input := blockchain.Input{PrevOut: unspent.PrevOut, Index: unspent.Index}
+	spend := func(inputs []blockchain.Input, receiver string, value int) blockchain.TransactionMulti {
+		tx := blockchain.NewTransactionMulti(inputs, []blockchain.Output{blockchain.Output{PubKeyHash: utils.MakeHashString(receiver), Value: value}}, 1)
+		if err := tx.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	first := spend([]blockchain.Input{input}, "first", 99)
+	second := spend([]blockchain.Input{input}, "second", 99)
+	twice := spend([]blockchain.Input{input, input}, "twice", 199)
+
+	if err := bc.VerifyTransaction(&twice); err == nil {
+		t.Error("Transaction spending an output twice should be rejected")
+	}
+	if err := bc.SubmitTransaction(&first, "peer"); err != nil {
+		t.Fatalf("First spend should be pooled %v", err)
+	}
+	if spendable := bc.GetSpendableOutputs(owner); len(spendable) != 0 {
+		t.Error("Output should not be spendable once the first spend is pooled")
+	}
+	if err := bc.SubmitTransaction(&second, "peer"); err == nil {
+		t.Error("Submitted double spend should be rejected")
+	}
+	if info, found := bc.GetTransactionInfo(first.String()); !found || !info.Pending {
+		t.Fatalf("First spend should be in the pool")
+	}
+	if _, found := bc.GetTransactionInfo(second.String()); found {
+		t.Error("Double spend of the pool should be rejected")
+	}
+	if err := bc.VerifyTransaction(&second); err == nil {
+		t.Error("Double spend of the pool should not be relayed")
+	}
+
+	reward := params.GetBlockReward(1)
+	invalid := []*blockchain.Block{
+		mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward+2, 1), first, second),
+		mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward+1, 1), twice),
+	}
+	for _, block := range invalid {
+		process(blockchain.ChainMessage{Block: block, Origin: "peer"})
+		if tip, _ := bc.GetTip(); tip != genesis.String() {
+			t.Fatalf("Block with a double spend should be rejected")
+		}
+	}
+
+	block1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward+1, 1), second)
+	process(blockchain.ChainMessage{Block: block1, Origin: "peer"})
+	if tip, _ := bc.GetTip(); tip != block1.String() {
+		t.Fatalf("Block spending the output once should be accepted")
+	}
+	if err := bc.VerifyTransaction(&second); err == nil {
+		t.Error("Transaction in the chain should be known")
+	}
+	if _, found := bc.GetTransactionInfo(first.String()); found {
+		t.Error("Pool spend of an output spent in a block should be dropped")
+	}
+	block2 := mineTestBlock(block1.Hash(), blockchain.NewCoinbase(miner, params.GetBlockReward(2)+1, 2), first)
+	process(blockchain.ChainMessage{Block: block2, Origin: "peer"})
+	if tip, _ := bc.GetTip(); tip != block1.String() {
+		t.Error("Block spending an output spent in the chain should be rejected")
+	}
+}
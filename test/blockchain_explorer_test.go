@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestExplorer(t *testing.T) {
+	t.Log("Testing explorer views of blocks, transactions and addresses")
+
+	key, owner := newTestKey(t)
+	receiver := utils.MakeHashString("receiver")
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	tx, err := bc.CreateTransaction(key, receiver, 30, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, params.GetBlockReward(1)+2, 1), *tx)
+	store := blockchain.NewMemoryStore()
+	store.SaveBlock(block)
+	bc, err = blockchain.NewBlockChain("test", miner, store, params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if blocks := bc.GetBlocks(0, 10); len(blocks) != 2 || blocks[1].Hash != block.String() {
+		t.Errorf("Canonical blocks not matching %v", len(blocks))
+	}
+	if blocks := bc.GetBlocks(1, 1); len(blocks) != 1 || blocks[0].Height != 1 {
+		t.Error("Blocks page not starting at height")
+	}
+	view, found := bc.GetBlockAtHeight(0)
+	if !found || view.Hash != genesis.String() || view.Confirmations != 2 || !view.Canonical {
+		t.Errorf("Genesis view not matching %+v", view)
+	}
+	if view, found := bc.GetBlockView(block.String()); !found || view.Height != 1 || len(view.Transactions) != 2 {
+		t.Errorf("Block view not matching %+v", view)
+	}
+	if _, found := bc.GetBlockAtHeight(2); found {
+		t.Error("Block above the tip should not be found")
+	}
+
+	info, found := bc.GetTransactionInfo(tx.String())
+	if !found || info.Block != block.String() || info.Confirmations != 1 || info.Pending {
+		t.Errorf("Transaction info not matching %+v", info)
+	}
+	if info.Transaction.Fee != 2 || info.Transaction.Outputs[0].Address != receiver.String() {
+		t.Errorf("Transaction view not matching %+v", info.Transaction)
+	}
+
+	history := bc.GetAddressHistory(owner)
+	if len(history) != 2 || history[0].Received != 100 || history[1].Sent != 100 || history[1].Received != 68 {
+		t.Errorf("Owner history not matching %+v", history)
+	}
+	if history := bc.GetAddressHistory(receiver); len(history) != 1 || history[0].Received != 30 || history[0].Height != 1 {
+		t.Errorf("Receiver history not matching %+v", history)
+	}
+}
+
+func TestExplorerDuringReorg(t *testing.T) {
+	t.Log("Testing explorer reads while the canonical chain is reorganized")
+
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{})
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+	defer bc.Stop()
+
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				bc.GetAddressHistory(miner)
+				bc.GetBlocks(0, 10)
+			}
+		}
+	}()
+	// every fork is one block longer and replaces the previous one
+	var tip *blockchain.Block
+	for fork := 1; fork <= 5; fork++ {
+		tip = genesis
+		for height := 1; height <= fork+1; height++ {
+			tip = mineTestBlock(tip.Hash(), blockchain.NewCoinbase(utils.MakeHashString(fmt.Sprintf("fork%v", fork)), params.GetBlockReward(height), height))
+			bc.ReceiveChannel <- blockchain.ChainMessage{Block: tip, Origin: "peer"}
+		}
+	}
+	bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+	close(done)
+	if name, height := bc.GetTip(); name != tip.String() || height != 7 {
+		t.Errorf("Chain not reorganized, tip %v at height %v", name, height)
+	}
+	for fork := 1; fork < 5; fork++ {
+		if history := bc.GetAddressHistory(utils.MakeHashString(fmt.Sprintf("fork%v", fork))); len(history) != 0 {
+			t.Errorf("History of replaced fork %v not rolled back %+v", fork, history)
+		}
+	}
+	if history := bc.GetAddressHistory(utils.MakeHashString("fork5")); len(history) != 6 || history[5].Height != 6 {
+		t.Errorf("History of canonical fork not matching %+v", history)
+	}
+	coinbase := tip.Body.Transactions[0].String()
+	if info, found := bc.GetTransactionInfo(coinbase); !found || info.Block != tip.String() || info.Height != 6 || info.Confirmations != 1 {
+		t.Errorf("Tip coinbase info not matching %+v", info)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestFeeEstimate(t *testing.T) {
+	t.Log("Testing fee estimation from recent blocks")
+
+	key, owner := newTestKey(t)
+	receiver := utils.MakeHashString("receiver")
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+	)
+	if estimate := bc.EstimateFee(1); estimate.Samples != 0 || estimate.Fee != blockchain.MIN_FEE || estimate.Size != blockchain.DEFAULT_TX_SIZE {
+		t.Errorf("Estimate without samples not matching %+v", estimate)
+	}
+
+	txs := []blockchain.TransactionMulti{}
+	for index, unspent := range bc.GetUnspentOutputs(owner) {
+		fee := []int{2, 10, 40}[index]
+		tx := blockchain.NewTransactionMulti(
+			[]blockchain.Input{blockchain.Input{PrevOut: unspent.PrevOut, Index: unspent.Index}},
+			[]blockchain.Output{blockchain.Output{PubKeyHash: receiver, Value: 100 - fee}}, fee)
+		if err := tx.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	coinbase := blockchain.NewCoinbase(miner, params.GetBlockReward(1)+52, 1)
+	block := mineTestBlock(genesis.Hash(), append([]blockchain.TransactionMulti{coinbase}, txs...)...)
+	store := blockchain.NewMemoryStore()
+	store.SaveBlock(block)
+	bc, err := blockchain.NewBlockChain("test", miner, store, params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate := func(tx blockchain.TransactionMulti) float64 {
+		return float64(tx.Fee) / float64(tx.GetSize())
+	}
+
+	next := bc.EstimateFee(1)
+	if next.Samples != 3 || next.ConfirmedRate != rate(txs[1]) || next.PoolRate != 0 {
+		t.Errorf("Next block estimate not matching %+v", next)
+	}
+	if next.Fee != int(math.Ceil(next.FeeRate*float64(next.Size))) || next.Fee < 9 {
+		t.Errorf("Fee not matching the rate %+v", next)
+	}
+	later := bc.EstimateFee(10)
+	if later.ConfirmedRate != rate(txs[0]) || later.Fee >= next.Fee {
+		t.Errorf("Later blocks should need a lower fee %+v", later)
+	}
+	if bc.EstimateFee(0).Blocks != 1 || bc.EstimateFee(1000).Blocks != blockchain.MAX_FEE_TARGET {
+		t.Error("Blocks not clamped")
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestGenesisBlock(t *testing.T) {
+	t.Log("Testing genesis block from network params")
+
+	owner := utils.MakeHashString("owner")
+	params := blockchain.NetworkParams{
+		NetworkID:   "gambercoin-unit",
+		Timestamp:   1540000000,
+		Target:      blockchain.PowLimit.String(),
+		BlockReward: 10,
+		Allocations: []blockchain.Allocation{blockchain.Allocation{Address: owner.String(), Value: 100}},
+	}
+	genesis, err := params.GenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := params.GenesisBlock()
+	if genesis.Hash() != again.Hash() {
+		t.Error("Genesis should be deterministic")
+	}
+
+	other := params
+	other.NetworkID = "gambercoin-other"
+	otherGenesis, _ := other.GenesisBlock()
+	if genesis.Hash() == otherGenesis.Hash() {
+		t.Error("Networks should have different genesis")
+	}
+
+	bc, err := blockchain.NewBlockChain("test", owner, blockchain.NewMemoryStore(), &params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bc.GetGenesisHash() != genesis.Hash() {
+		t.Error("Chain not started from genesis")
+	}
+	if balance := bc.GetBalanceOfHash(owner); balance != 100 {
+		t.Errorf("Allocation not applied, balance %v", balance)
+	}
+
+	params.Target = "00"
+	if _, err := params.GenesisBlock(); err == nil {
+		t.Error("Short target should be rejected")
+	}
+	if _, err := blockchain.LoadNetworkParams("unknown-network.json"); err == nil {
+		t.Error("Unknown network should be rejected")
+	}
+}
+
+func TestBlockReward(t *testing.T) {
+	t.Log("Testing block reward schedule")
+
+	params := blockchain.NetworkParams{BlockReward: 50, HalvingInterval: 10}
+	rewards := map[int]int{0: 50, 9: 50, 10: 25, 25: 12, 60: 0, 1000: 0}
+	for height, reward := range rewards {
+		if value := params.GetBlockReward(height); value != reward {
+			t.Errorf("Reward at height %v is %v expected %v", height, value, reward)
+		}
+	}
+	params.HalvingInterval = 0
+	if value := params.GetBlockReward(1000); value != 50 {
+		t.Errorf("Reward should not halve, got %v", value)
+	}
+}
+
+func TestCoinbaseLimit(t *testing.T) {
+	t.Log("Testing coinbase reward and fees")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, owner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	tx, err := bc.CreateTransaction(key, miner, 10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.GetOutputValue()+tx.Fee != 100 {
+		t.Errorf("Fee not left out of the outputs %v", tx.GetOutputValue())
+	}
+
+	reward := params.GetBlockReward(1)
+	cases := []struct {
+		txs   []blockchain.TransactionMulti
+		valid bool
+	}{
+		{[]blockchain.TransactionMulti{blockchain.NewCoinbase(miner, reward+3, 1), *tx}, true},
+		{[]blockchain.TransactionMulti{blockchain.NewCoinbase(miner, reward+4, 1), *tx}, false},
+		{[]blockchain.TransactionMulti{*tx, blockchain.NewCoinbase(miner, reward, 1)}, false},
+		{[]blockchain.TransactionMulti{blockchain.NewCoinbase(miner, 1, 1), blockchain.NewCoinbase(miner, 1, 1)}, false},
+		{[]blockchain.TransactionMulti{blockchain.NewCoinbase(miner, reward, 5)}, false},
+	}
+	for index, test := range cases {
+		block := mineTestBlock(genesis.Hash(), test.txs...)
+		store := blockchain.NewMemoryStore()
+		store.SaveBlock(block)
+		store.SaveTip(block)
+		restored, err := blockchain.NewBlockChain("test", owner, store, params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tip, _ := restored.GetTip(); (tip == block.String()) != test.valid {
+			t.Errorf("Case %v should be valid: %v", index, test.valid)
+		}
+	}
+}
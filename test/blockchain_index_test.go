@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestBlockIndex(t *testing.T) {
+	t.Log("Testing BlockIndex struct")
+
+	miner := utils.MakeHashString("miner")
+	params, _ := blockchain.LoadNetworkParams("test")
+	genesis, _ := params.GenesisBlock()
+	index := blockchain.NewBlockIndex(genesis)
+
+	blockA1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, 1, 1))
+	blockA2 := mineTestBlock(blockA1.Hash(), blockchain.NewCoinbase(miner, 1, 2))
+	blockB1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, 2, 1))
+	blockB2 := mineTestBlock(blockB1.Hash(), blockchain.NewCoinbase(miner, 2, 2))
+	blockB3 := mineTestBlock(blockB2.Hash(), blockchain.NewCoinbase(miner, 2, 3))
+
+	index.Add(blockA1)
+	index.Add(blockA2)
+	if best := index.GetBestTip(); best.Hash != blockA2.String() || best.Height != 2 {
+		t.Errorf("Best tip should be A2, got %v", best.Hash)
+	}
+
+	if _, orphan := index.Add(blockB3); !orphan {
+		t.Error("Block without known parent should be an orphan")
+	}
+	index.Add(blockB2)
+	if missing := index.GetMissingParents(); len(missing) != 1 || missing[0] != blockB1.String() {
+		t.Errorf("Missing parent should be B1, got %v", missing)
+	}
+	connected, _ := index.Add(blockB1)
+	if len(connected) != 3 {
+		t.Errorf("Orphans not connected, %v connected", len(connected))
+	}
+	best := index.GetBestTip()
+	if best.Hash != blockB3.String() || best.Height != 3 {
+		t.Errorf("Best tip should be B3, got %v", best.Hash)
+	}
+	if expected := blockchain.GetBlockWork(blockchain.PowLimit); best.Work.Cmp(expected.Mul(expected, big.NewInt(4))) != 0 {
+		t.Errorf("Cumulative work not tracked %v", best.Work)
+	}
+
+	nodeA2, _ := index.Get(blockA2.String())
+	fork := blockchain.FindFork(nodeA2, best)
+	if fork.Hash != genesis.String() {
+		t.Errorf("Fork should be the genesis, got %v", fork.Hash)
+	}
+	if path := blockchain.GetPath(fork, best); len(path) != 3 || path[0].Hash != blockB1.String() {
+		t.Error("Path from fork not valid")
+	}
+
+	nodeB2, _ := index.Get(blockB2.String())
+	index.MarkInvalid(nodeB2)
+	if best := index.GetBestTip(); best.Hash != blockA2.String() {
+		t.Errorf("Invalid branch should not be the best tip, got %v", best.Hash)
+	}
+}
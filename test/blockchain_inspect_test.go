@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestReorgInspection(t *testing.T) {
+	t.Log("Testing pending transactions, fork blocks and reorgs")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+	defer bc.Stop()
+	process := func(blocks ...*blockchain.Block) {
+		for _, block := range blocks {
+			bc.ReceiveChannel <- blockchain.ChainMessage{Block: block, Origin: "peer"}
+		}
+		// the previous message is processed once this one is received
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+	}
+
+	tx, err := bc.CreateTransaction(key, miner, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reward := params.GetBlockReward(1)
+	blockA := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward+2, 1), *tx)
+	process(blockA)
+	if pending := bc.GetPendingTransactions(); len(pending) != 0 {
+		t.Errorf("Confirmed transaction should not be pending %v", len(pending))
+	}
+
+	blockB1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(utils.MakeHashString("other"), reward, 1))
+	blockB2 := mineTestBlock(blockB1.Hash(), blockchain.NewCoinbase(utils.MakeHashString("other"), reward, 2))
+	orphan := mineTestBlock(utils.MakeHashString("unknown"), blockchain.NewCoinbase(miner, reward, 5))
+	// the child first so the fork is connected at once
+	process(blockB2, blockB1, orphan)
+	if tip, _ := bc.GetTip(); tip != blockB2.String() {
+		t.Fatalf("Chain with more work should win, tip %v", tip)
+	}
+
+	reorgs := bc.GetReorgs()
+	if len(reorgs) != 1 {
+		t.Fatalf("Reorg not recorded %v", len(reorgs))
+	}
+	reorg := reorgs[0]
+	if reorg.Depth != 1 || reorg.OldTip != blockA.String() || reorg.NewTip != blockB2.String() || reorg.NewHeight != 2 {
+		t.Errorf("Reorg not matching %+v", reorg)
+	}
+	if len(reorg.Returned) != 1 || reorg.Returned[0] != tx.String() {
+		t.Errorf("Returned transactions not matching %v", reorg.Returned)
+	}
+
+	pending := bc.GetPendingTransactions()
+	if len(pending) != 1 || pending[0].Fee != 2 || pending[0].FeeRate <= 0 || pending[0].Age < 0 {
+		t.Errorf("Pending transactions not matching %+v", pending)
+	}
+
+	forks := bc.GetForkBlocks()
+	if len(forks) != 2 || forks[0].Hash != blockA.String() || forks[0].Height != 1 {
+		t.Fatalf("Fork blocks not matching %+v", forks)
+	}
+	if !forks[1].Orphan || forks[1].Hash != orphan.String() {
+		t.Errorf("Orphan block not listed %+v", forks[1])
+	}
+}
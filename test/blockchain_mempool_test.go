@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func mempoolTestTx(name string, fee int) *blockchain.TransactionMulti {
+	tx := blockchain.NewTransactionMulti(
+		[]blockchain.Input{blockchain.Input{PrevOut: utils.MakeHashString(name), Index: 0}},
+		[]blockchain.Output{blockchain.Output{PubKeyHash: utils.MakeHashString("receiver"), Value: 10}},
+		fee)
+	return &tx
+}
+
+func TestMempool(t *testing.T) {
+	t.Log("Testing Mempool struct")
+
+	config := blockchain.MempoolConfig{MaxCount: 3, MaxBytes: 1024 * 1024, Expiry: 60}
+	pool := blockchain.NewMempool(config)
+	low, mid, high := mempoolTestTx("low", 1), mempoolTestTx("mid", 5), mempoolTestTx("high", 9)
+	for _, tx := range []*blockchain.TransactionMulti{mid, low, high} {
+		if _, err := pool.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	txs := pool.GetTransactions()
+	if len(txs) != 3 || txs[0] != high || txs[1] != mid || txs[2] != low {
+		t.Error("Transactions not ordered by fee rate")
+	}
+	if spender, found := pool.GetSpender(utils.MakeHashString("mid"), 0); !found || spender != mid.String() {
+		t.Error("Spent output not tracked")
+	}
+
+	if _, err := pool.Add(mempoolTestTx("lower", 0)); err == nil {
+		t.Error("Full pool should reject lower fee rates")
+	}
+	evicted, err := pool.Add(mempoolTestTx("higher", 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 1 || evicted[0] != low || pool.Has(low.String()) || pool.Count() != 3 {
+		t.Error("Lowest fee rate not evicted")
+	}
+	if _, found := pool.GetSpender(utils.MakeHashString("low"), 0); found {
+		t.Error("Evicted transaction still spends its outputs")
+	}
+
+	size := high.GetSize()
+	selected := pool.SelectForBlock(2*size+10, 1, 0)
+	if len(selected) != 2 || selected[0] != high {
+		t.Errorf("Block selection should pick the best two, got %v", len(selected))
+	}
+
+	if expired := pool.Expire(time.Now().Unix(), 1, 0); len(expired) != 0 {
+		t.Error("Transactions expired too early")
+	}
+	if expired := pool.Expire(time.Now().Unix()+config.Expiry+1, 1, 0); len(expired) != 3 || pool.Count() != 0 || pool.Bytes() != 0 {
+		t.Error("Transactions not expired")
+	}
+}
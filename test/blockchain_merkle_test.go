@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestMerkleProof(t *testing.T) {
+	t.Log("Testing merkle proofs")
+
+	miner := utils.MakeHashString("miner")
+	for size := 1; size <= 7; size++ {
+		block := blockchain.NewBlock([32]byte{})
+		for index := 0; index < size; index++ {
+			block.AppendTransaction(blockchain.NewCoinbase(miner, 1, index))
+		}
+		root := block.Header.MerkleRoot
+		if root != block.ComputeMerkleRoot() {
+			t.Errorf("Header merkle root not updated for size %v", size)
+		}
+		for _, tx := range block.Body.Transactions {
+			proof, found := block.GetMerkleProof(tx.Name)
+			if !found {
+				t.Fatalf("Proof not found for %v", tx.String())
+			}
+			if !blockchain.VerifyMerkleProof(tx.Name, root, proof) {
+				t.Errorf("Proof of index %v not valid for size %v", proof.Index, size)
+			}
+			if blockchain.VerifyMerkleProof(utils.MakeHashString("other"), root, proof) {
+				t.Errorf("Proof of index %v valid for another transaction", proof.Index)
+			}
+		}
+	}
+
+	block := blockchain.NewBlock([32]byte{})
+	if _, found := block.GetMerkleProof(miner); found {
+		t.Error("Proof found for a transaction not in the block")
+	}
+}
+
+func TestBlockHeaderHash(t *testing.T) {
+	t.Log("Testing block header hash")
+
+	miner := utils.MakeHashString("miner")
+	block := blockchain.NewBlock([32]byte{})
+	block.AppendTransaction(blockchain.NewCoinbase(miner, 1, 0))
+	hash := block.Hash()
+
+	block.Header.Nonce++
+	if block.Hash() == hash {
+		t.Error("Nonce not included in the header hash")
+	}
+	block.Header.Nonce--
+	block.AppendTransaction(blockchain.NewCoinbase(miner, 1, 1))
+	if block.Hash() == hash {
+		t.Error("Transactions not committed in the header hash")
+	}
+	hash = block.Hash()
+	block.Body.Transactions = block.Body.Transactions[:1]
+	if block.Hash() != hash {
+		t.Error("Header hash should not depend on the body")
+	}
+	if block.Header.MerkleRoot == block.ComputeMerkleRoot() {
+		t.Error("Merkle root should not match a modified body")
+	}
+}
+
+func TestMerkleRootMutation(t *testing.T) {
+	t.Log("Testing blocks repeating their last transactions")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+	)
+	txs := []blockchain.TransactionMulti{blockchain.NewCoinbase(miner, params.GetBlockReward(1)+2, 1)}
+	for _, unspent := range bc.GetUnspentOutputs(owner) {
+		tx := blockchain.NewTransactionMulti(
+			[]blockchain.Input{blockchain.Input{PrevOut: unspent.PrevOut, Index: unspent.Index}},
+			[]blockchain.Output{blockchain.Output{PubKeyHash: miner, Value: 99}}, 1)
+		if err := tx.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	block := mineTestBlock(genesis.Hash(), txs...)
+	mutated := *block
+	mutated.Body.Transactions = append(append([]blockchain.TransactionMulti{}, txs...), txs[2])
+	if mutated.ComputeMerkleRoot() != block.Header.MerkleRoot || mutated.String() != block.String() {
+		t.Fatal("Repeating the last transaction should keep the merkle root")
+	}
+
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+	defer bc.Stop()
+	process := func(block *blockchain.Block) string {
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: block, Origin: "peer"}
+		// the previous message is processed once this one is received
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+		tip, _ := bc.GetTip()
+		return tip
+	}
+	if process(&mutated) != genesis.String() {
+		t.Error("Block with repeated transactions should be rejected")
+	}
+	// signatures are not committed by the merkle root
+	resigned := *block
+	resigned.Body.Transactions = append([]blockchain.TransactionMulti{}, txs...)
+	resigned.Body.Transactions[1].Inputs = []blockchain.Input{txs[1].Inputs[0]}
+	resigned.Body.Transactions[1].Inputs[0].Signature = txs[2].Inputs[0].Signature
+	if resigned.ComputeMerkleRoot() != block.Header.MerkleRoot || process(&resigned) != genesis.String() {
+		t.Error("Block with a changed signature should be rejected")
+	}
+	if process(block) != block.String() {
+		t.Error("Block should be accepted after copies with a changed body")
+	}
+}
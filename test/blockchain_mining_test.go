@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestMiningControl(t *testing.T) {
+	t.Log("Testing mining on and off and the reward address")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	payout := utils.MakeHashString("payout")
+	bc, params, _ := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.InstantSeal{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	if err := bc.SetMiningThreads(2); err == nil {
+		t.Error("Instant seal should not accept threads")
+	}
+	bc.SetMiningEnabled(false)
+	sent := bc.Start(func() {})
+	defer bc.Stop()
+	go func() {
+		for range sent {
+		}
+	}()
+
+	tx, err := bc.CreateTransaction(key, miner, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc.ReceiveChannel <- blockchain.ChainMessage{Tx: tx, Origin: "peer"}
+	time.Sleep(100 * time.Millisecond)
+	if _, height := bc.GetTip(); height != 1 {
+		t.Errorf("Relay only chain should not seal blocks, height %v", height)
+	}
+
+	bc.SetMinerHash(payout)
+	bc.SetMiningEnabled(true)
+	if height := waitForHeight(bc, 2); height != 2 {
+		t.Fatalf("Block not sealed after enabling mining, height %v", height)
+	}
+	if balance := bc.GetBalanceOfHash(payout); balance != params.GetBlockReward(1) {
+		t.Errorf("Reward not paid to the new address, balance %v", balance)
+	}
+	stats := bc.GetMiningStats()
+	if !stats.Enabled || stats.BlocksFound != 1 || stats.RewardAddress != payout.String() {
+		t.Errorf("Mining stats not matching %+v", stats)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/ageapps/gambercoin/pkg/wallet"
+)
+
+func TestRawTransaction(t *testing.T) {
+	t.Log("Testing unsigned transactions signed offline")
+
+	signer := wallet.NewMemoryWallet()
+	signer.SetKeySize(1024)
+	owner, err := signer.NewAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver := utils.MakeHashString("receiver")
+	bc, _, _ := newTestChain(t, utils.MakeHashString("miner"), blockchain.NewMemoryStore(), &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	unspent := bc.GetUnspentOutputs(owner)
+	if len(unspent) != 1 {
+		t.Fatalf("Allocation not found %v", unspent)
+	}
+	inputs := []blockchain.Input{blockchain.Input{PrevOut: unspent[0].PrevOut, Index: unspent[0].Index}}
+
+	if _, err := bc.CreateRawTransaction(inputs, []blockchain.Output{blockchain.Output{PubKeyHash: receiver, Value: 101}}, 0); err == nil {
+		t.Error("Outputs over the inputs should be rejected")
+	}
+	unknown := []blockchain.Input{blockchain.Input{PrevOut: receiver, Index: 0}}
+	if _, err := bc.CreateRawTransaction(unknown, []blockchain.Output{blockchain.Output{PubKeyHash: receiver, Value: 1}}, 0); err == nil {
+		t.Error("Unknown outputs should be rejected")
+	}
+
+	unsigned, err := bc.CreateRawTransaction(inputs, []blockchain.Output{
+		blockchain.Output{PubKeyHash: receiver, Value: 60},
+		blockchain.Output{PubKeyHash: owner, Value: 37},
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsigned.Transaction.Fee != 3 || len(unsigned.Spent) != 1 || unsigned.Spent[0].Address != owner.String() {
+		t.Errorf("Unsigned transaction not matching %v", unsigned)
+	}
+	tx, err := blockchain.DecodeTransaction(unsigned.Tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.VerifyTransaction(tx); err == nil {
+		t.Error("Unsigned transaction should not be valid")
+	}
+	if err := unsigned.CheckSpent(tx); err != nil {
+		t.Errorf("Spent outputs should match the transaction %v", err)
+	}
+	// a node lying about the spent values hides part of the fee
+	lying := *unsigned
+	lying.Spent = []blockchain.SpentOutput{unsigned.Spent[0]}
+	lying.Spent[0].Value = 97
+	if err := lying.CheckSpent(tx); err == nil {
+		t.Error("Spent values not matching the outputs plus the fee should be rejected")
+	}
+	lying.Spent[0] = unsigned.Spent[0]
+	lying.Spent[0].Index++
+	if err := lying.CheckSpent(tx); err == nil {
+		t.Error("Spent outputs not matching the inputs should be rejected")
+	}
+
+	// the offline signer only knows the unsigned transaction
+	if err := signer.Sign(tx, unsigned); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := blockchain.EncodeTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := blockchain.DecodeTransaction(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.VerifyTransaction(signed); err != nil {
+		t.Errorf("Signed transaction not valid %v", err)
+	}
+
+	tampered := *signed
+	tampered.Outputs = []blockchain.Output{blockchain.Output{PubKeyHash: receiver, Value: 97}}
+	raw, _ = blockchain.EncodeTransaction(&tampered)
+	if _, err := blockchain.DecodeTransaction(raw); err == nil {
+		t.Error("Transaction with a name not matching should be rejected")
+	}
+}
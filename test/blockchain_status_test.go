@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestTransactionStatus(t *testing.T) {
+	t.Log("Testing transaction status tracking and notifications")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	bc.WatchAddresses(func(address utils.HashValue) bool { return address == owner || address == miner })
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+	defer bc.Stop()
+	process := func(messages ...blockchain.ChainMessage) {
+		for _, message := range messages {
+			bc.ReceiveChannel <- message
+		}
+		// the previous message is processed once this one is received
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+	}
+	lastStatus := func(address utils.HashValue, since uint64) *blockchain.Notification {
+		notifications := bc.GetNotifications(address, since)
+		if len(notifications) == 0 {
+			return nil
+		}
+		return &notifications[len(notifications)-1]
+	}
+
+	if status := bc.GetTransactionStatus(miner.String()); status.Status != blockchain.TX_UNKNOWN {
+		t.Errorf("Transaction should be unknown %+v", status)
+	}
+
+	tx, err := bc.CreateTransaction(key, miner, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	process(blockchain.ChainMessage{Tx: tx, Origin: "peer"})
+	if status := bc.GetTransactionStatus(tx.String()); status.Status != blockchain.TX_PENDING {
+		t.Errorf("Transaction should be pending %+v", status)
+	}
+	// the allocation is spent and the change paid back
+	notifications := bc.GetNotifications(owner, 0)
+	if len(notifications) != 2 || notifications[0].Incoming || notifications[0].Value != 100 || notifications[1].Value != 88 {
+		t.Fatalf("Pending payments not notified %+v", notifications)
+	}
+	last := &notifications[1]
+
+	reward := params.GetBlockReward(1)
+	blockA := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward+2, 1), *tx)
+	process(blockchain.ChainMessage{Block: blockA, Origin: "peer"})
+	status := bc.GetTransactionStatus(tx.String())
+	if status.Status != blockchain.TX_CONFIRMED || status.Block != blockA.String() || status.Height != 1 || status.Confirmations != 1 {
+		t.Errorf("Transaction should be confirmed %+v", status)
+	}
+	if last := lastStatus(owner, last.ID); last == nil || last.Status != blockchain.TX_CONFIRMED || last.Height != 1 {
+		t.Errorf("Confirmation not notified %+v", last)
+	}
+
+	blockB1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(utils.MakeHashString("other"), reward, 1))
+	blockB2 := mineTestBlock(blockB1.Hash(), blockchain.NewCoinbase(utils.MakeHashString("other"), reward, 2))
+	process(blockchain.ChainMessage{Block: blockB2, Origin: "peer"}, blockchain.ChainMessage{Block: blockB1, Origin: "peer"})
+	status = bc.GetTransactionStatus(tx.String())
+	if status.Status != blockchain.TX_PENDING || !strings.Contains(status.Reason, blockA.String()) {
+		t.Errorf("Reorged transaction should be back in the pool %+v", status)
+	}
+	coinbase := bc.GetTransactionStatus(blockA.Body.Transactions[0].String())
+	if coinbase.Status != blockchain.TX_REORGED {
+		t.Errorf("Reorged coinbase not tracked %+v", coinbase)
+	}
+	if last := lastStatus(miner, 0); last == nil || last.Status != blockchain.TX_REORGED || !last.Incoming {
+		t.Errorf("Reorg not notified %+v", last)
+	}
+
+	// spends the allocation already spent by the pending transaction
+	double := blockchain.NewTransactionMulti(tx.Inputs, []blockchain.Output{blockchain.Output{PubKeyHash: owner, Value: 100}}, 0)
+	double.Sign(key)
+	process(blockchain.ChainMessage{Tx: &double, Origin: "peer"})
+	status = bc.GetTransactionStatus(double.String())
+	if status.Status != blockchain.TX_REJECTED || !strings.Contains(status.Reason, "double spend") {
+		t.Errorf("Double spend should be rejected %+v", status)
+	}
+}
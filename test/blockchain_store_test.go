@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+// testBlockTime increases with every mined test
+// block so that they pass the median time check
+var testBlockTime = time.Now().Unix() - 3600
+
+func mineTestBlock(prev [32]byte, txs ...blockchain.TransactionMulti) *blockchain.Block {
+	testBlockTime++
+	block := blockchain.NewBlock(prev)
+	block.Header.Timestamp = testBlockTime
+	block.Header.Target = blockchain.PowLimit
+	for _, tx := range txs {
+		block.AppendTransaction(tx)
+	}
+	for !blockchain.MeetsTarget(block.Hash(), block.Header.Target) {
+		block.Header.Nonce++
+	}
+	return block
+}
+
+// newTestKey returns a key and the address it owns
+func newTestKey(t *testing.T) (*rsa.PrivateKey, utils.HashValue) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, blockchain.GetPubKeyHash(&key.PublicKey)
+}
+
+// newTestChain of the test network with the allocations in its genesis block
+func newTestChain(t *testing.T, miner utils.HashValue, store blockchain.BlockStore, engine blockchain.ConsensusEngine, allocations ...blockchain.Allocation) (*blockchain.BlockChain, *blockchain.NetworkParams, *blockchain.Block) {
+	params, err := blockchain.LoadNetworkParams("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.Allocations = allocations
+	genesis, err := params.GenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc, err := blockchain.NewBlockChain("test", miner, store, params, blockchain.DefaultMempoolConfig(), engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bc, params, genesis
+}
+
+func TestFileStore(t *testing.T) {
+	t.Log("Testing FileStore struct")
+
+	dir := t.TempDir()
+	miner := utils.MakeHashString("miner")
+	block1 := mineTestBlock([32]byte{}, blockchain.NewCoinbase(miner, 1, 0))
+	block2 := mineTestBlock(block1.Hash(), blockchain.NewCoinbase(miner, 1, 1))
+	tx := blockchain.NewCoinbase(miner, 1, 2)
+
+	store, err := blockchain.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	store.SaveBlock(block1)
+	store.SaveBlock(block2)
+	store.SaveBlock(block2)
+	store.SaveTip(block2)
+	store.SaveTransaction(&tx)
+	store.DeleteTransaction(tx.Name)
+	store.SaveTransaction(&tx)
+	store.Close()
+
+	// simulate a record cut by a crash
+	file, err := os.OpenFile(path.Join(dir, blockchain.STORE_FILE), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{1, 200, 0})
+	file.Close()
+
+	store, err = blockchain.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	stored, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Blocks) != 2 || stored.Blocks[1].String() != block2.String() {
+		t.Errorf("Blocks not restored %v", len(stored.Blocks))
+	}
+	if stored.Tip != block2.String() {
+		t.Errorf("Tip not restored %v", stored.Tip)
+	}
+	if len(stored.Transactions) != 1 || stored.Transactions[0].Name != tx.Name {
+		t.Errorf("Transactions not restored %v", len(stored.Transactions))
+	}
+}
+
+func TestFileStoreRecordSize(t *testing.T) {
+	t.Log("Testing FileStore with a corrupt record length")
+
+	dir := t.TempDir()
+	miner := utils.MakeHashString("miner")
+	block := mineTestBlock([32]byte{}, blockchain.NewCoinbase(miner, 1, 0))
+	store, err := blockchain.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SaveBlock(block)
+	store.Close()
+
+	file, err := os.OpenFile(path.Join(dir, blockchain.STORE_FILE), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{1, 0xff, 0xff, 0xff, 0xff, 0})
+	file.Close()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	store, err = blockchain.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	stored, err := store.Load()
+	runtime.ReadMemStats(&after)
+	if err != nil || len(stored.Blocks) != 1 {
+		t.Fatalf("Records before the corrupt one not restored %v", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > blockchain.MaxBlockSize*100 {
+		t.Errorf("Corrupt length allocated %v bytes", allocated)
+	}
+}
+
+func TestBlockChainRestore(t *testing.T) {
+	t.Log("Testing BlockChain restore from store")
+
+	miner := utils.MakeHashString("miner")
+	store := blockchain.NewMemoryStore()
+	params, err := blockchain.LoadNetworkParams("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, _ := params.GenesisBlock()
+	block1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, 1, 1))
+	block2 := mineTestBlock(block1.Hash(), blockchain.NewCoinbase(miner, 1, 2))
+	fork := mineTestBlock(block1.Hash(), blockchain.NewCoinbase(miner, 5, 2))
+	block3 := mineTestBlock(block2.Hash(), blockchain.NewCoinbase(miner, 1, 3))
+	// children stored before their parents are connected once it is loaded
+	store.SaveBlock(block3)
+	store.SaveBlock(block1)
+	store.SaveBlock(fork)
+	store.SaveBlock(block2)
+	store.SaveTip(block3)
+
+	bc, err := blockchain.NewBlockChain("test", miner, store, params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip, height := bc.GetTip(); tip != block3.String() || height != 4 {
+		t.Errorf("Tip not restored %v at height %v", tip, height)
+	}
+	if balance := bc.GetBalanceOfHash(miner); balance != 3 {
+		t.Errorf("Balance not restored %v", balance)
+	}
+}
+
+func TestFileStoreRestart(t *testing.T) {
+	t.Log("Testing restarts do not write the restored chain again")
+
+	dir := t.TempDir()
+	miner := utils.MakeHashString("miner")
+	params, _ := blockchain.LoadNetworkParams("test")
+	genesis, _ := params.GenesisBlock()
+	restart := func() (string, int64) {
+		store, err := blockchain.NewFileStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+		bc, err := blockchain.NewBlockChain("test", miner, store, params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tip, _ := bc.GetTip()
+		info, err := os.Stat(path.Join(dir, blockchain.STORE_FILE))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tip, info.Size()
+	}
+	restart()
+	store, err := blockchain.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Load()
+	prev := genesis
+	for height := 1; height < 10; height++ {
+		prev = mineTestBlock(prev.Hash(), blockchain.NewCoinbase(miner, params.GetBlockReward(height), height))
+		store.SaveBlock(prev)
+	}
+	store.Close()
+
+	tip, size := restart()
+	if tip != prev.String() {
+		t.Fatalf("Chain not restored, tip %v", tip)
+	}
+	for i := 0; i < 3; i++ {
+		if tip, restartedSize := restart(); tip != prev.String() || restartedSize != size {
+			t.Errorf("Store grew from %v to %v bytes on restart", size, restartedSize)
+		}
+	}
+}
+
+func TestStrippedBlockNotStored(t *testing.T) {
+	t.Log("Testing a block copy without signatures is not stored")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	store := blockchain.NewMemoryStore()
+	bc, params, genesis := newTestChain(t, miner, store, &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 100})
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+
+	unspent := bc.GetUnspentOutputs(owner)[0]
+	input := blockchain.Input{PrevOut: unspent.PrevOut, Index: unspent.Index}
+	tx := blockchain.NewTransactionMulti([]blockchain.Input{input}, []blockchain.Output{blockchain.Output{PubKeyHash: miner, Value: 99}}, 1)
+	if err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	block1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, params.GetBlockReward(1)+1, 1), tx)
+	// same header and merkle root, the signatures are not part of them
+	stripped := *block1
+	stripped.Body.Transactions = append([]blockchain.TransactionMulti{}, block1.Body.Transactions...)
+	stripped.Body.Transactions[1].Inputs = []blockchain.Input{input}
+	for _, block := range []*blockchain.Block{&stripped, block1, genesis} {
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: block, Origin: "peer"}
+	}
+	if tip, _ := bc.GetTip(); tip != block1.String() {
+		t.Fatalf("Valid block should be accepted after the stripped copy")
+	}
+	bc.Stop()
+
+	restored, err := blockchain.NewBlockChain("test", miner, store, params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip, height := restored.GetTip(); tip != block1.String() || height != 2 {
+		t.Errorf("Valid block not restored, tip %v at height %v", tip, height)
+	}
+}
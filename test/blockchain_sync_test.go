@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestChainSync(t *testing.T) {
+	t.Log("Testing locators, hashes and blocks exchanged to sync")
+
+	miner := utils.MakeHashString("miner")
+	params, _ := blockchain.LoadNetworkParams("test")
+	genesis, _ := params.GenesisBlock()
+	store := blockchain.NewMemoryStore()
+	blocks := []*blockchain.Block{genesis}
+	// stays under the second retarget, test blocks are too fast for it
+	for height := 1; height < 2*blockchain.RetargetInterval; height++ {
+		block := mineTestBlock(blocks[height-1].Hash(), blockchain.NewCoinbase(miner, 1, height))
+		store.SaveBlock(block)
+		blocks = append(blocks, block)
+	}
+	store.SaveTip(blocks[19])
+	bc, err := blockchain.NewBlockChain("test", miner, store, params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locator := bc.GetLocator()
+	if locator[0] != blocks[19].String() || locator[len(locator)-1] != genesis.String() {
+		t.Errorf("Locator should go from the tip to the genesis %v", locator)
+	}
+	// ten consecutive blocks and then doubling steps
+	if len(locator) != 13 || locator[9] != blocks[10].String() || locator[10] != blocks[8].String() || locator[11] != blocks[4].String() {
+		t.Errorf("Locator steps not matching %v", locator)
+	}
+
+	unknown := utils.MakeHashString("unknown")
+	hashes := bc.GetHashesAfterLocator([]string{unknown.String(), blocks[5].String()}, 3)
+	if len(hashes) != 3 || hashes[0] != blocks[6].String() || hashes[2] != blocks[8].String() {
+		t.Errorf("Hashes after the locator not matching %v", hashes)
+	}
+	if hashes := bc.GetHashesAfterLocator([]string{blocks[19].String()}, 3); len(hashes) != 0 {
+		t.Errorf("Peer at the tip should get no hashes %v", hashes)
+	}
+
+	requested := []string{unknown.String()}
+	for _, block := range blocks[1:] {
+		requested = append(requested, block.String())
+	}
+	if served := bc.GetRequestedBlocks(requested, 5); len(served) != 5 || served[0].String() != blocks[1].String() {
+		t.Errorf("Requested blocks should be capped and skip unknown ones %v", len(served))
+	}
+
+	// a peer with only the genesis catches up
+	peer, err := blockchain.NewBlockChain("peer", miner, blockchain.NewMemoryStore(), params, blockchain.DefaultMempoolConfig(), &blockchain.ProofOfWork{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer.SetMiningEnabled(false)
+	peer.Start(func() {})
+	defer peer.Stop()
+	hashes = bc.GetHashesAfterLocator(peer.GetLocator(), 100)
+	for _, block := range bc.GetRequestedBlocks(hashes, 100) {
+		peer.ReceiveChannel <- blockchain.ChainMessage{Block: block, Origin: "bc"}
+	}
+	peer.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "bc"}
+	if tip, height := peer.GetTip(); tip != blocks[19].String() || height != 20 {
+		t.Errorf("Peer not synced, tip %v at height %v", tip, height)
+	}
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestTimelockedTransactions(t *testing.T) {
+	t.Log("Testing transactions locked until a height or a time")
+
+	key, owner := newTestKey(t)
+	receiver := utils.MakeHashString("receiver")
+	miner := utils.MakeHashString("miner")
+	bc, params, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+	)
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+	defer bc.Stop()
+	process := func(messages ...blockchain.ChainMessage) {
+		for _, message := range messages {
+			bc.ReceiveChannel <- message
+		}
+		// the previous message is processed once this one is received
+		bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+	}
+	locked := func(unspent blockchain.UnspentOutput, lockTime int64) blockchain.TransactionMulti {
+		tx := blockchain.NewTransactionMulti(
+			[]blockchain.Input{blockchain.Input{PrevOut: unspent.PrevOut, Index: unspent.Index}},
+			[]blockchain.Output{blockchain.Output{PubKeyHash: receiver, Value: 99}}, 1)
+		tx.SetLockTime(lockTime)
+		if err := tx.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	unspent := bc.GetUnspentOutputs(owner)
+	byHeight := locked(unspent[0], 2)
+	byTime := locked(unspent[1], time.Now().Unix()+60*60)
+	if byHeight.GetLockHeight() != 2 || byHeight.GetLockTimestamp() != 0 || byTime.GetLockHeight() != 0 || byTime.GetLockTimestamp() != byTime.LockTime {
+		t.Error("Lock kind not matching the lock time")
+	}
+	expiry := blockchain.DefaultMempoolConfig().Expiry
+	farHeight := locked(unspent[0], 2+expiry/blockchain.TargetBlockTime)
+	farTime := locked(unspent[1], time.Now().Unix()+2*expiry)
+	for _, tx := range []blockchain.TransactionMulti{farHeight, farTime} {
+		if err := bc.VerifyTransaction(&tx); err == nil {
+			t.Errorf("Transaction locked until %v, after the pool expiry, should be rejected", tx.LockTime)
+		}
+	}
+
+	process(blockchain.ChainMessage{Tx: &byHeight, Origin: "peer"}, blockchain.ChainMessage{Tx: &byTime, Origin: "peer"})
+	for _, tx := range []blockchain.TransactionMulti{byHeight, byTime} {
+		info, found := bc.GetTransactionInfo(tx.String())
+		if !found || !info.Pending || !info.Locked {
+			t.Fatalf("Immature transaction should wait in the pool %+v", info)
+		}
+		if info.Transaction.LockHeight != tx.GetLockHeight() || info.Transaction.LockTime != tx.GetLockTimestamp() {
+			t.Errorf("Lock not reported %+v", info.Transaction)
+		}
+	}
+	for _, pending := range bc.GetPendingTransactions() {
+		if !pending.Locked {
+			t.Errorf("Pending transaction should be locked %+v", pending)
+		}
+	}
+
+	reward := params.GetBlockReward(1)
+	for _, tx := range []blockchain.TransactionMulti{byHeight, byTime} {
+		immature := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward+1, 1), tx)
+		process(blockchain.ChainMessage{Block: immature, Origin: "peer"})
+		if tip, _ := bc.GetTip(); tip != genesis.String() {
+			t.Fatalf("Block with an immature transaction locked until %v should be rejected", tx.LockTime)
+		}
+	}
+
+	block1 := mineTestBlock(genesis.Hash(), blockchain.NewCoinbase(miner, reward, 1))
+	block2 := mineTestBlock(block1.Hash(), blockchain.NewCoinbase(miner, params.GetBlockReward(2)+1, 2), byHeight)
+	process(blockchain.ChainMessage{Block: block1, Origin: "peer"}, blockchain.ChainMessage{Block: block2, Origin: "peer"})
+	if tip, _ := bc.GetTip(); tip != block2.String() {
+		t.Fatalf("Mature transaction should be mined at its lock height")
+	}
+	if info, found := bc.GetTransactionInfo(byTime.String()); !found || !info.Locked {
+		t.Errorf("Transaction locked by time should still wait %+v", info)
+	}
+
+	pool := blockchain.NewMempool(blockchain.DefaultMempoolConfig())
+	pool.Add(&byHeight)
+	if selected := pool.SelectForBlock(blockchain.MaxBlockSize, 1, 0); len(selected) != 0 {
+		t.Error("Immature transaction should not be selected")
+	}
+	if selected := pool.SelectForBlock(blockchain.MaxBlockSize, 2, 0); len(selected) != 1 {
+		t.Error("Mature transaction should be selected")
+	}
+	later := time.Now().Unix() + expiry + 1
+	if expired := pool.Expire(later, 1, 0); len(expired) != 0 {
+		t.Error("Immature transaction should not expire")
+	}
+	if expired := pool.Expire(later+expiry, 2, 0); len(expired) != 0 {
+		t.Error("Expiry should count from the maturity")
+	}
+	if expired := pool.Expire(later+expiry+1, 2, 0); len(expired) != 1 {
+		t.Error("Mature transaction should expire")
+	}
+}
+
+func TestTimelockedMining(t *testing.T) {
+	t.Log("Testing proof of work mining until a locked transaction matures")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, _, _ := newTestChain(t, miner, blockchain.NewMemoryStore(), blockchain.NewProofOfWork(1), blockchain.Allocation{Address: owner.String(), Value: 100})
+	sent := bc.Start(func() {})
+	defer bc.Stop()
+	go func() {
+		for range sent {
+		}
+	}()
+
+	unspent := bc.GetUnspentOutputs(owner)[0]
+	tx := blockchain.NewTransactionMulti(
+		[]blockchain.Input{blockchain.Input{PrevOut: unspent.PrevOut, Index: unspent.Index}},
+		[]blockchain.Output{blockchain.Output{PubKeyHash: miner, Value: 99}}, 1)
+	tx.SetLockTime(3)
+	if err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.SubmitTransaction(&tx, "peer"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	info, _ := bc.GetTransactionInfo(tx.String())
+	for info.Pending && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		info, _ = bc.GetTransactionInfo(tx.String())
+	}
+	if info.Pending || info.Height != 3 {
+		t.Errorf("Locked transaction should be mined once it matures %+v", info)
+	}
+}
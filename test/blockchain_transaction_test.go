@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestTransactionSignature(t *testing.T) {
+	t.Log("Testing TransactionMulti signatures")
+
+	key, owner := newTestKey(t)
+	coinbase := blockchain.NewCoinbase(owner, 10, 0)
+	if !coinbase.IsCoinbase() {
+		t.Errorf("Transaction %v should be a coinbase", coinbase.String())
+	}
+
+	inputs := []blockchain.Input{blockchain.Input{PrevOut: coinbase.Name, Index: 0}}
+	outputs := []blockchain.Output{blockchain.Output{PubKeyHash: utils.MakeHashString(testString), Value: 10}}
+	tx := blockchain.NewTransactionMulti(inputs, outputs, 0)
+	if tx.IsCoinbase() {
+		t.Errorf("Transaction %v should not be a coinbase", tx.String())
+	}
+	if err := tx.VerifySignature(); err == nil {
+		t.Error("Unsigned transaction should not be valid")
+	}
+	if err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.VerifySignature(); err != nil {
+		t.Errorf("Signed transaction should be valid %v", err)
+	}
+	if blockchain.GetPubKeyHash(&key.PublicKey) != owner {
+		t.Error("Public key hash should be deterministic")
+	}
+
+	tx.Outputs[0].Value = 20
+	if err := tx.VerifySignature(); err == nil {
+		t.Error("Modified transaction should not be valid")
+	}
+}
+
+func TestTransactionValueOverflow(t *testing.T) {
+	t.Log("Testing values that overflow when added")
+
+	key, owner := newTestKey(t)
+	miner := utils.MakeHashString("miner")
+	bc, _, genesis := newTestChain(t, miner, blockchain.NewMemoryStore(), &blockchain.ProofOfWork{}, blockchain.Allocation{Address: owner.String(), Value: 1})
+	unspent := bc.GetUnspentOutputs(owner)[0]
+	inputs := []blockchain.Input{blockchain.Input{PrevOut: unspent.PrevOut, Index: unspent.Index}}
+	// the outputs add up to 1 once the sum wraps around
+	outputs := []blockchain.Output{
+		blockchain.Output{PubKeyHash: miner, Value: math.MaxInt64},
+		blockchain.Output{PubKeyHash: miner, Value: math.MaxInt64},
+		blockchain.Output{PubKeyHash: miner, Value: 3},
+	}
+	tx := blockchain.NewTransactionMulti(inputs, outputs, 0)
+	if err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.VerifyTransaction(&tx); err == nil {
+		t.Error("Outputs over the maximum money should be rejected")
+	}
+	if _, err := bc.CreateRawTransaction(inputs, outputs, 0); err == nil {
+		t.Error("Raw transaction over the maximum money should be rejected")
+	}
+	fee := blockchain.NewTransactionMulti(inputs, []blockchain.Output{blockchain.Output{PubKeyHash: miner, Value: 3}}, math.MaxInt64-1)
+	if err := fee.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.VerifyTransaction(&fee); err == nil {
+		t.Error("Fee over the maximum money should be rejected")
+	}
+
+	coinbase := blockchain.NewTransactionMulti([]blockchain.Input{blockchain.Input{Index: 1}}, outputs, 0)
+	block := mineTestBlock(genesis.Hash(), coinbase)
+	bc.SetMiningEnabled(false)
+	bc.Start(func() {})
+	defer bc.Stop()
+	bc.ReceiveChannel <- blockchain.ChainMessage{Block: block, Origin: "peer"}
+	bc.ReceiveChannel <- blockchain.ChainMessage{Block: genesis, Origin: "peer"}
+	if tip, _ := bc.GetTip(); tip != genesis.String() {
+		t.Error("Coinbase over the maximum money should be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+)
+
+func TestUTXOSet(t *testing.T) {
+	t.Log("Testing UTXOSet struct")
+
+	miner := utils.MakeHashString("miner")
+	receiver := utils.MakeHashString("receiver")
+	set := blockchain.NewUTXOSet()
+
+	block1 := blockchain.NewBlock([32]byte{})
+	coinbase := blockchain.NewCoinbase(miner, 10, 0)
+	block1.AppendTransaction(coinbase)
+	set.ApplyBlock(block1)
+
+	if set.GetBalance(miner) != 10 || set.Size() != 1 {
+		t.Errorf("Coinbase not indexed, balance %v", set.GetBalance(miner))
+	}
+
+	block2 := blockchain.NewBlock(block1.Hash())
+	block2.AppendTransaction(blockchain.NewCoinbase(miner, 10, 1))
+	spend := blockchain.NewTransactionMulti(
+		[]blockchain.Input{blockchain.Input{PrevOut: coinbase.Name, Index: 0}},
+		[]blockchain.Output{
+			blockchain.Output{PubKeyHash: receiver, Value: 4},
+			blockchain.Output{PubKeyHash: miner, Value: 6},
+		}, 0)
+	block2.AppendTransaction(spend)
+	set.ApplyBlock(block2)
+
+	if set.GetBalance(miner) != 16 || set.GetBalance(receiver) != 4 {
+		t.Errorf("Balances not updated %v %v", set.GetBalance(miner), set.GetBalance(receiver))
+	}
+	if _, found := set.Get(coinbase.Name, 0); found {
+		t.Error("Spent output should not be found")
+	}
+	if unspent := set.GetUnspentOutputs(receiver); len(unspent) != 1 || unspent[0].Output.Value != 4 {
+		t.Errorf("Unspent outputs not matching %v", unspent)
+	}
+
+	set.RollbackBlock(block2)
+	if set.GetBalance(miner) != 10 || set.GetBalance(receiver) != 0 || set.Size() != 1 {
+		t.Errorf("Rollback not restoring balances %v %v", set.GetBalance(miner), set.GetBalance(receiver))
+	}
+	if _, found := set.Get(coinbase.Name, 0); !found {
+		t.Error("Spent output should be restored")
+	}
+}
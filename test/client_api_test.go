@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/client"
+)
+
+func TestClientAPI(t *testing.T) {
+	t.Log("Testing client of the node HTTP server")
+
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/balance":
+			if r.URL.Query().Get("name") != "nodeA" || r.URL.Query().Get("hash") != "abcd" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, "42")
+		case "/address/abcd/history":
+			fmt.Fprint(w, `[{"tx":"01","received":5}]`)
+		case "/transaction":
+			json.NewDecoder(r.Body).Decode(&sent)
+			fmt.Fprint(w, `{"tx":"02"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Error: peer not found")
+		}
+	}))
+	defer server.Close()
+
+	api := client.NewAPI(server.URL+"/", "nodeA")
+	if balance, err := api.GetBalance("abcd"); err != nil || balance != 42 {
+		t.Errorf("Balance not matching %v %v", balance, err)
+	}
+	history := []map[string]interface{}{}
+	if err := api.GetHistory("abcd", &history); err != nil || len(history) != 1 || history[0]["tx"] != "01" {
+		t.Errorf("History not matching %v %v", history, err)
+	}
+	if hash, err := api.SendTransaction(client.ClientTx{Out: "abcd", Amount: 3, Fee: 1}); err != nil || hash != "02" {
+		t.Fatalf("Transaction not sent %v %v", hash, err)
+	}
+	if sent["name"] != "nodeA" || sent["out"] != "abcd" || sent["amount"] != 3.0 || sent["fee"] != 1.0 {
+		t.Errorf("Transaction body not matching %v", sent)
+	}
+	if _, err := api.NewAddress(); err == nil {
+		t.Error("Server error should be returned")
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/ageapps/gambercoin/pkg/wallet"
+)
+
+func makeUnspent(values ...int) []blockchain.UnspentOutput {
+	unspent := []blockchain.UnspentOutput{}
+	for index, value := range values {
+		unspent = append(unspent, blockchain.UnspentOutput{
+			PrevOut: utils.MakeHashString("prev"),
+			Index:   index,
+			Output:  blockchain.Output{PubKeyHash: utils.MakeHashString("owner"), Value: value},
+		})
+	}
+	return unspent
+}
+
+func TestCoinSelection(t *testing.T) {
+	t.Log("Testing coin selection strategies")
+
+	unspent := makeUnspent(5, 20, 8, 50, 12)
+
+	selection, err := wallet.SelectCoins(unspent, 30, 2, 3, wallet.SELECTION_LARGEST_FIRST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selection.Outputs) != 1 || selection.Value != 50 || selection.Change != 18 || selection.Fee != 2 {
+		t.Errorf("Largest first not matching %+v", selection)
+	}
+
+	// 20 + 12 matches the amount plus the fee without change
+	selection, err = wallet.SelectCoins(unspent, 30, 2, 3, wallet.SELECTION_BRANCH_AND_BOUND)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selection.Value != 32 || selection.Change != 0 || selection.Fee != 2 {
+		t.Errorf("Branch and bound not matching %+v", selection)
+	}
+
+	// 20 + 12 leaves 1 coin under the dust threshold to the miner
+	selection, _ = wallet.SelectCoins(unspent, 29, 2, 3, wallet.SELECTION_BRANCH_AND_BOUND)
+	if selection.Value != 32 || selection.Change != 0 || selection.Fee != 3 {
+		t.Errorf("Dust not left to the miner %+v", selection)
+	}
+
+	// no set matches 60, largest first is used
+	selection, _ = wallet.SelectCoins(unspent, 60, 0, 1, wallet.SELECTION_BRANCH_AND_BOUND)
+	if selection.Value != 70 || selection.Change != 10 || selection.Fee != 0 {
+		t.Errorf("Fallback not matching %+v", selection)
+	}
+
+	if _, err := wallet.SelectCoins(unspent, 95, 1, 3, wallet.SELECTION_LARGEST_FIRST); err == nil {
+		t.Error("Selection over the funds should fail")
+	}
+	if _, err := wallet.SelectCoins(unspent, 10, 0, 3, "random"); err == nil {
+		t.Error("Unknown strategy should fail")
+	}
+	if _, err := wallet.SelectCoins(makeUnspent(blockchain.MAX_MONEY, blockchain.MAX_MONEY), blockchain.MAX_MONEY, blockchain.MAX_MONEY, 3, wallet.SELECTION_LARGEST_FIRST); err == nil {
+		t.Error("Amount plus fee over the max money should fail")
+	}
+}
+
+func TestWalletCreateTransaction(t *testing.T) {
+	t.Log("Testing wallet transactions with change outputs")
+
+	w := wallet.NewMemoryWallet()
+	w.SetKeySize(1024)
+	owner, _ := w.NewAddress()
+	receiver := utils.MakeHashString("receiver")
+	bc, _, _ := newTestChain(t, utils.MakeHashString("miner"), blockchain.NewMemoryStore(), &blockchain.ProofOfWork{},
+		blockchain.Allocation{Address: owner.String(), Value: 100},
+		blockchain.Allocation{Address: owner.String(), Value: 40},
+	)
+	if err := w.SetCoinSelection(wallet.SELECTION_LARGEST_FIRST, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := w.CreateTransaction(bc, nil, receiver, 30, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Inputs) != 1 || len(tx.Outputs) != 2 || tx.Outputs[1].Value != 68 {
+		t.Fatalf("Change output not matching %+v", tx.Outputs)
+	}
+	change := tx.Outputs[1].PubKeyHash
+	if change == owner || !w.HasAddress(change) {
+		t.Error("Change should be paid to a new wallet address")
+	}
+	if err := bc.VerifyTransaction(tx); err != nil {
+		t.Errorf("Transaction not valid %v", err)
+	}
+
+	if _, err := w.CreateTransaction(bc, []utils.HashValue{receiver}, owner, 10, 0, 0); err == nil {
+		t.Error("Address out of the wallet should not pay")
+	}
+	if _, err := w.CreateTransaction(bc, nil, receiver, 200, 0, 0); err == nil {
+		t.Error("Transaction over the balance should fail")
+	}
+	if _, err := w.CreateTransaction(bc, nil, receiver, wallet.DEFAULT_DUST_THRESHOLD-1, 0, 0); err == nil {
+		t.Error("Amount under the dust threshold should fail")
+	}
+	tx, err = w.CreateTransaction(bc, []utils.HashValue{owner, owner}, receiver, 130, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Inputs) != 2 || tx.Inputs[0].Index == tx.Inputs[1].Index {
+		t.Error("Repeated address should not repeat inputs")
+	}
+}
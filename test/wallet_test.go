@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ageapps/gambercoin/pkg/blockchain"
+	"github.com/ageapps/gambercoin/pkg/utils"
+	"github.com/ageapps/gambercoin/pkg/wallet"
+)
+
+func TestWalletKeystore(t *testing.T) {
+	t.Log("Testing wallet keystore encryption")
+
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, wallet.WALLET_FILE)
+
+	w, err := wallet.Create(file, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetKeySize(1024)
+	first, err := w.NewAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := w.NewAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wallet.Create(file, "secret"); err == nil {
+		t.Error("Existing keystore should not be overwritten")
+	}
+	if _, err := wallet.Create(path.Join(dir, "empty.json"), ""); err == nil {
+		t.Error("Keystore without password should not be created")
+	}
+	if _, err := wallet.Open(file, "wrong"); err == nil {
+		t.Error("Keystore opened with a wrong password")
+	}
+	if content, _ := ioutil.ReadFile(file); len(content) == 0 {
+		t.Error("Keystore not written")
+	}
+
+	reopened, err := wallet.Open(file, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addresses := reopened.GetAddresses(); len(addresses) != 2 || addresses[0] != first || addresses[1] != second {
+		t.Errorf("Addresses not restored %v", addresses)
+	}
+	if err := reopened.SetDefaultAddress(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.SetDefaultAddress(utils.MakeHashString("unknown")); err == nil {
+		t.Error("Unknown address should not be the default")
+	}
+	reopened, _ = wallet.OpenOrCreate(file, "secret")
+	if address, _ := reopened.GetDefaultAddress(); address != second {
+		t.Errorf("Default address not stored %v", address)
+	}
+}
+
+func TestWalletSign(t *testing.T) {
+	t.Log("Testing wallet balances and signatures")
+
+	w := wallet.NewMemoryWallet()
+	w.SetKeySize(1024)
+	first, _ := w.NewAddress()
+	key, owner := newTestKey(t)
+	imported, err := w.ImportKey(key)
+	if err != nil || imported != owner || !w.HasAddress(imported) {
+		t.Fatalf("Key not imported %v", err)
+	}
+
+	set := blockchain.NewUTXOSet()
+	block := blockchain.NewBlock([32]byte{})
+	coinbaseA := blockchain.NewCoinbase(first, 10, 0)
+	coinbaseB := blockchain.NewCoinbase(imported, 5, 1)
+	block.AppendTransaction(coinbaseA)
+	block.AppendTransaction(coinbaseB)
+	block.AppendTransaction(blockchain.NewCoinbase(utils.MakeHashString("other"), 7, 2))
+	set.ApplyBlock(block)
+
+	if balance := w.GetBalance(set); balance != 15 {
+		t.Errorf("Wallet balance not matching %v", balance)
+	}
+
+	tx := blockchain.NewTransactionMulti(
+		[]blockchain.Input{
+			blockchain.Input{PrevOut: coinbaseA.Name, Index: 0},
+			blockchain.Input{PrevOut: coinbaseB.Name, Index: 0},
+		},
+		[]blockchain.Output{blockchain.Output{PubKeyHash: utils.MakeHashString("receiver"), Value: 14}}, 1)
+	if err := w.Sign(&tx, set); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.VerifySignature(); err != nil {
+		t.Errorf("Signature not valid %v", err)
+	}
+
+	foreign := blockchain.NewTransactionMulti(
+		[]blockchain.Input{blockchain.Input{PrevOut: block.Body.Transactions[2].Name, Index: 0}},
+		[]blockchain.Output{blockchain.Output{PubKeyHash: first, Value: 7}}, 0)
+	if err := w.Sign(&foreign, set); err == nil {
+		t.Error("Output of another address should not be signed")
+	}
+}